@@ -0,0 +1,19 @@
+//go:build embedassets
+
+package main
+
+import (
+	"embed"
+
+	"sdlshoot/game"
+)
+
+// 嵌入到可执行文件中的资源，不包括存档、按键绑定和回放等玩家数据
+// 用 go build -tags embedassets 构建单文件版本
+//
+//go:embed assets/data assets/effect assets/font assets/image assets/music assets/sound
+var embeddedAssets embed.FS
+
+func init() {
+	game.SetAssetFS(embeddedAssets)
+}
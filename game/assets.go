@@ -0,0 +1,429 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sync"
+
+	"github.com/SunshineZzzz/purego-sdl3/img"
+	"github.com/SunshineZzzz/purego-sdl3/sdl"
+	"github.com/SunshineZzzz/purego-sdl3/ttf"
+)
+
+// 引用计数归零的资源再保留几次场景切换，从结束场景重新开始时不用重新载入
+const assetIdleSwitches = 1
+
+// 同时载入资源的后台协程数量
+const assetWorkers = 4
+
+// 资源类型
+type assetKind int32
+
+const (
+	// 纹理
+	assetTexture assetKind = iota
+	// 字体
+	assetFont
+	// WAV音效
+	assetSound
+	// OGG音乐
+	assetMusic
+)
+
+// 资源的键
+type assetKey struct {
+	// 资源类型
+	kind assetKind
+	// 路径
+	path string
+	// 字号（assetFont）
+	size float32
+}
+
+// 字体和字号，同一字体不同字号分别缓存
+type fontKey struct {
+	// 路径
+	path string
+	// 字号
+	size float32
+}
+
+// 资源清单，场景初始化时预载，清理时释放
+type assetManifest struct {
+	// 纹理路径
+	textures []string
+	// 字体
+	fonts []fontKey
+	// WAV音效路径
+	sounds []string
+	// OGG音乐路径
+	music []string
+}
+
+// 清单中所有资源的键，无头模式下只有纹理
+func (m *assetManifest) keys() []assetKey {
+	keys := make([]assetKey, 0, len(m.textures)+len(m.fonts)+len(m.sounds)+len(m.music))
+	for _, path := range m.textures {
+		keys = append(keys, assetKey{kind: assetTexture, path: path})
+	}
+	if GetInstance().headless {
+		return keys
+	}
+	for _, font := range m.fonts {
+		keys = append(keys, assetKey{kind: assetFont, path: font.path, size: font.size})
+	}
+	for _, path := range m.sounds {
+		keys = append(keys, assetKey{kind: assetSound, path: path})
+	}
+	for _, path := range m.music {
+		keys = append(keys, assetKey{kind: assetMusic, path: path})
+	}
+	return keys
+}
+
+// 纹理和尺寸，无头模式下只有尺寸
+type textureAsset struct {
+	// 纹理
+	texture *sdl.Texture
+	// 宽度
+	width float32
+	// 高度
+	height float32
+}
+
+// 字体，从内存中的字体文件打开，文件数据要和字体一起保留
+type fontAsset struct {
+	// 字体
+	font *ttf.Font
+	// 字体文件数据
+	data []byte
+}
+
+// 缓存中的资源
+type cachedAsset struct {
+	// 资源，*textureAsset、*fontAsset、*wavData或者*oggData
+	value any
+	// 载入失败的原因
+	err error
+	// 是否已经载入完成，失败也算完成
+	loaded bool
+	// 引用计数
+	refs int32
+	// 引用计数归零时的场景切换次数
+	idleSince uint64
+}
+
+// 后台载入的结果，由主线程完成剩余工作后放入缓存
+type assetResult struct {
+	// 资源的键
+	key assetKey
+	// 后台载入的数据，纹理为图片表面，字体为文件数据
+	value any
+	// 载入失败的原因
+	err error
+}
+
+// 资源管理器，按路径缓存纹理、字体、WAV音效和OGG音乐，引用计数归零后延迟释放
+// 文件读取和解码在后台协程进行，纹理和字体在主线程创建
+type assetManager struct {
+	// 缓存的资源
+	cache map[assetKey]*cachedAsset
+	// 限制同时载入的后台协程数量
+	workers chan struct{}
+	// 正在载入的后台协程
+	pending sync.WaitGroup
+	// 保护finished
+	mutex sync.Mutex
+	// 后台载入完成，等待主线程处理的结果
+	finished []assetResult
+	// 有新的载入结果时通知主线程
+	signal chan struct{}
+	// 场景切换次数
+	switches uint64
+	// 第一次读取资源时查找资源包和资源目录
+	sourceOnce sync.Once
+	// 资源包，没有资源包时为nil
+	pack *assetPack
+	// 散装资源文件所在的文件系统，可以是操作系统目录或者嵌入的文件系统
+	fsys fs.FS
+	// 玩家数据（排行榜、按键绑定、回放）所在的目录，和查找资源的目录相同
+	dataDir string
+}
+
+// 创建资源管理器
+func newAssetManager() *assetManager {
+	return &assetManager{
+		cache:   make(map[assetKey]*cachedAsset),
+		workers: make(chan struct{}, assetWorkers),
+		signal:  make(chan struct{}, 1),
+	}
+}
+
+// 正在进行的一次资源载入
+type assetLoad struct {
+	// 资源管理器
+	manager *assetManager
+	// 载入的资源
+	keys []assetKey
+}
+
+// 开始载入清单中的资源，增加所有资源的引用，不在缓存中的资源交给后台协程载入
+// 载入完成后由update放入缓存，不论成功与否都要用release释放清单
+func (m *assetManager) load(manifest *assetManifest) *assetLoad {
+	keys := manifest.keys()
+	for _, key := range keys {
+		if asset, ok := m.cache[key]; ok {
+			asset.refs++
+			continue
+		}
+		m.cache[key] = &cachedAsset{refs: 1}
+		m.pending.Add(1)
+		go m.read(key)
+	}
+	return &assetLoad{manager: m, keys: keys}
+}
+
+// 后台协程读取并解码资源
+func (m *assetManager) read(key assetKey) {
+	defer m.pending.Done()
+	m.workers <- struct{}{}
+	value, err := m.readAsset(key)
+	<-m.workers
+
+	m.mutex.Lock()
+	m.finished = append(m.finished, assetResult{key: key, value: value, err: err})
+	m.mutex.Unlock()
+	select {
+	case m.signal <- struct{}{}:
+	default:
+	}
+}
+
+// 读取并解码资源，在后台协程调用，不能创建纹理
+func (m *assetManager) readAsset(key assetKey) (any, error) {
+	data, err := m.readFile(key.path)
+	if err != nil {
+		return nil, err
+	}
+	switch key.kind {
+	case assetTexture:
+		// 无头模式下只读取图片尺寸
+		if GetInstance().headless {
+			width, height, err := imageSize(key.path, data)
+			if err != nil {
+				return nil, err
+			}
+			return &textureAsset{width: width, height: height}, nil
+		}
+		surface := img.LoadIO(sdl.IOFromConstMem(data), true)
+		if surface == nil {
+			return nil, fmt.Errorf("failed to decode image, %v, %v", key.path, sdl.GetError())
+		}
+		return surface, nil
+	case assetFont:
+		return data, nil
+	case assetSound:
+		return loadWav(key.path, data)
+	default:
+		return loadOgg(key.path, data)
+	}
+}
+
+// 在主线程完成资源载入，由图片表面创建纹理，由字体文件数据打开字体
+func finishAsset(key assetKey, value any) (any, error) {
+	switch v := value.(type) {
+	case *sdl.Surface:
+		defer sdl.DestroySurface(v)
+		texture := sdl.CreateTextureFromSurface(GetInstance().sdlRenderer, v)
+		if texture == nil {
+			return nil, fmt.Errorf("failed to create texture, %v, %v", key.path, sdl.GetError())
+		}
+		return &textureAsset{texture: texture, width: float32(v.W), height: float32(v.H)}, nil
+	case []byte:
+		font := ttf.OpenFontIO(sdl.IOFromConstMem(v), true, key.size)
+		if font == nil {
+			return nil, fmt.Errorf("failed to open font, %v, %v", key.path, sdl.GetError())
+		}
+		return &fontAsset{font: font, data: v}, nil
+	}
+	return value, nil
+}
+
+// 释放资源或者后台载入的数据
+func freeAsset(value any) {
+	switch v := value.(type) {
+	case *sdl.Surface:
+		sdl.DestroySurface(v)
+	case *textureAsset:
+		if v.texture != nil {
+			sdl.DestroyTexture(v.texture)
+		}
+	case *fontAsset:
+		ttf.CloseFont(v.font)
+	case *wavData:
+		v.free()
+	}
+	// OGG的PCM数据由GC回收
+}
+
+// 在主线程处理后台载入的结果，每帧调用
+func (m *assetManager) update() {
+	m.mutex.Lock()
+	finished := m.finished
+	m.finished = nil
+	m.mutex.Unlock()
+
+	for _, result := range finished {
+		asset, ok := m.cache[result.key]
+		if !ok || asset.loaded {
+			// 载入期间已经从缓存中移除
+			if result.err == nil {
+				freeAsset(result.value)
+			}
+			continue
+		}
+		asset.loaded = true
+		asset.err = result.err
+		if result.err == nil {
+			asset.value, asset.err = finishAsset(result.key, result.value)
+		}
+	}
+}
+
+// 等待所有后台协程结束并处理结果
+func (m *assetManager) wait() {
+	m.pending.Wait()
+	m.update()
+}
+
+// 同步预载清单中的所有资源，任意资源载入失败时释放清单，返回所有失败原因
+func (m *assetManager) preload(manifest *assetManifest) error {
+	load := m.load(manifest)
+	for {
+		m.update()
+		if load.done() {
+			break
+		}
+		<-m.signal
+	}
+	if err := load.err(); err != nil {
+		m.release(manifest)
+		return err
+	}
+	return nil
+}
+
+// 释放清单中所有资源的引用，和load或者preload成对使用
+func (m *assetManager) release(manifest *assetManifest) {
+	for _, key := range manifest.keys() {
+		asset, ok := m.cache[key]
+		if !ok || asset.refs == 0 {
+			continue
+		}
+		asset.refs--
+		if asset.refs == 0 {
+			asset.idleSince = m.switches
+		}
+	}
+}
+
+// 场景切换后调用，释放空闲太久的资源
+func (m *assetManager) sceneSwitched() {
+	m.switches++
+	m.collect(false)
+}
+
+// 释放空闲资源，all为false时保留最近空闲的资源，载入失败的资源空闲后立即移除以便重试
+func (m *assetManager) collect(all bool) {
+	for key, asset := range m.cache {
+		if asset.refs > 0 {
+			continue
+		}
+		if !all && asset.loaded && asset.err == nil && m.switches-asset.idleSince <= assetIdleSwitches {
+			continue
+		}
+		if asset.loaded && asset.err == nil {
+			freeAsset(asset.value)
+		}
+		delete(m.cache, key)
+	}
+}
+
+// 载入成功的资源，不在缓存中或者没有载入完成时返回nil
+func (m *assetManager) get(key assetKey) any {
+	if asset, ok := m.cache[key]; ok && asset.loaded && asset.err == nil {
+		return asset.value
+	}
+	return nil
+}
+
+// 预载过的纹理，不在缓存中时返回nil
+func (m *assetManager) texture(path string) *textureAsset {
+	texture, _ := m.get(assetKey{kind: assetTexture, path: path}).(*textureAsset)
+	return texture
+}
+
+// 预载过的字体，不在缓存中时返回nil
+func (m *assetManager) font(path string, size float32) *ttf.Font {
+	if font, ok := m.get(assetKey{kind: assetFont, path: path, size: size}).(*fontAsset); ok {
+		return font.font
+	}
+	return nil
+}
+
+// 由预载过的WAV音效创建播放器
+func (m *assetManager) newSoundPlayer(path string) (*wavPlayer, error) {
+	data, ok := m.get(assetKey{kind: assetSound, path: path}).(*wavData)
+	if !ok {
+		return nil, fmt.Errorf("sound not preloaded, %v", path)
+	}
+	return newWavPlayer(data)
+}
+
+// 由预载过的OGG音乐创建播放器
+func (m *assetManager) newMusicPlayer(path string) (*oggPlayer, error) {
+	data, ok := m.get(assetKey{kind: assetMusic, path: path}).(*oggData)
+	if !ok {
+		return nil, fmt.Errorf("music not preloaded, %v", path)
+	}
+	return newOggPlayer(data)
+}
+
+// 载入进度，0到1
+func (l *assetLoad) progress() float32 {
+	if len(l.keys) == 0 {
+		return 1
+	}
+	loaded := 0
+	for _, key := range l.keys {
+		if asset, ok := l.manager.cache[key]; ok && asset.loaded {
+			loaded++
+		}
+	}
+	return float32(loaded) / float32(len(l.keys))
+}
+
+// 是否所有资源都已经载入完成，失败也算完成
+func (l *assetLoad) done() bool {
+	for _, key := range l.keys {
+		if asset, ok := l.manager.cache[key]; ok && !asset.loaded {
+			return false
+		}
+	}
+	return true
+}
+
+// 所有载入失败的原因，没有失败时返回nil
+func (l *assetLoad) err() error {
+	var errs []error
+	for _, key := range l.keys {
+		if asset, ok := l.manager.cache[key]; ok && asset.err != nil {
+			errs = append(errs, asset.err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to load assets, %w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package game
+
+import (
+	"github.com/SunshineZzzz/purego-sdl3/sdl"
+)
+
+// 炸弹闪光时间，毫秒
+const bombFlashMs = 300
+
+// 使用炸弹：清除所有敌人子弹，伤害所有敌人和Boss，之后短暂无敌
+func (s *sceneMain) useBomb() {
+	if s.isDead || s.player.bombs <= 0 {
+		return
+	}
+	s.player.bombs--
+	currentTime := s.currentTime()
+	s.bombTime = currentTime
+	s.player.invincible.start(currentTime, s.bomb.InvincibleMs, s.bomb.InvincibleMs)
+	s.clearEnemyProjectiles()
+
+	for e := range s.targets() {
+		s.damages = append(s.damages, damageEvent{target: e, amount: s.bomb.Damage})
+	}
+	if b := s.boss; b != nil && !b.dying && !b.isEntering() {
+		b.currentHealth -= s.bomb.BossDamage
+	}
+
+	// 全屏随机爆炸
+	for i := int32(0); i < s.bomb.ExplosionCount; i++ {
+		x := s.rand.Float32() * s.fieldWidth
+		y := s.rand.Float32() * s.fieldHeight
+		s.addExplosion(sdl.FPoint{X: x, Y: y})
+	}
+	s.playSound("player_explode")
+}
+
+// 拾取炸弹，不超过上限
+func (s *sceneMain) addBomb() {
+	s.player.bombs = min(s.player.maxBombs, s.player.bombs+1)
+}
+
+// 炸弹闪光，逐渐变淡
+func (s *sceneMain) renderBombFlash() {
+	if s.bombTime == 0 {
+		return
+	}
+	elapsed := s.currentTime() - s.bombTime
+	if elapsed >= bombFlashMs {
+		return
+	}
+	rect := sdl.FRect{
+		X: 0,
+		Y: 0,
+		W: float32(GetInstance().windowWidth),
+		H: float32(GetInstance().windowHeight),
+	}
+	alpha := uint8(200 * (bombFlashMs - elapsed) / bombFlashMs)
+	GetInstance().renderFillRect(rect, sdl.Color{R: 255, G: 255, B: 255, A: alpha})
+}
+
+// 在血条下方渲染剩余炸弹
+func (s *sceneMain) renderBombs() {
+	x := float32(10)
+	y := float32(50)
+	size := float32(24)
+	offset := float32(28)
+	for i := int32(0); i < s.player.bombs; i++ {
+		rect := sdl.FRect{
+			X: x + float32(i)*offset,
+			Y: y,
+			W: size,
+			H: size,
+		}
+		sdl.RenderTexture(GetInstance().sdlRenderer, s.bombIcon, nil, &rect)
+	}
+}
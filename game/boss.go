@@ -0,0 +1,339 @@
+package game
+
+import (
+	"math"
+
+	"github.com/SunshineZzzz/purego-sdl3/sdl"
+)
+
+const (
+	// 死亡动画时间，毫秒
+	bossDeathMs = 2500
+	// 死亡动画中爆炸的间隔，毫秒
+	bossDeathExplosionMs = 120
+	// 弱点受击闪烁时间，毫秒
+	bossHitFlashMs = 80
+	// 最后一次爆炸的数量
+	bossFinalExplosionCount = 6
+)
+
+// 激光状态
+const (
+	// 未发射
+	laserStateIdle int32 = iota
+	// 预警
+	laserStateWarn
+	// 发射中
+	laserStateFire
+)
+
+// Boss类型
+type bossType struct {
+	// 类型名字
+	name string
+	// Boss模板
+	template boss
+	// 击毁得分
+	score uint32
+	// 击中弱点以外部位的伤害倍数
+	bodyDamageScale float32
+	// 摆动幅度
+	swayAmplitude float32
+	// 摆动频率
+	swayFrequency float32
+	// 入场后停留的高度
+	enterY float32
+	// 弱点
+	weakPoints []weakPointDef
+	// 阶段
+	phases []bossPhaseDef
+}
+
+// Boss单个攻击的运行状态
+type bossAttack struct {
+	def *bossAttackDef
+	// 上次攻击时间
+	lastTime uint64
+	// 弹幕发射器
+	emitter emitter
+	// 激光状态（laser）
+	laserState int32
+	// 激光进入当前状态的时间（laser）
+	laserTime uint64
+	// 本次激光是否已经击中玩家（laser）
+	laserHit bool
+}
+
+// 是否还在入场
+func (b *boss) isEntering() bool {
+	return b.position.Y < b.kind.enterY
+}
+
+// 弱点的碰撞矩形
+func (b *boss) weakPointRect(point *weakPointDef) sdl.FRect {
+	return sdl.FRect{
+		X: b.position.X + point.X*b.width,
+		Y: b.position.Y + point.Y*b.height,
+		W: point.W * b.width,
+		H: point.H * b.height,
+	}
+}
+
+// 发射口位置，在Boss底部中间
+func (b *boss) muzzle() sdl.FPoint {
+	return sdl.FPoint{X: b.position.X + b.width/2, Y: b.position.Y + b.height*0.8}
+}
+
+// 激光的碰撞矩形，从发射口一直到游戏区域底部bottom
+func (b *boss) laserRect(width float32, bottom float32) sdl.FRect {
+	muzzle := b.muzzle()
+	return sdl.FRect{
+		X: muzzle.X - width/2,
+		Y: muzzle.Y,
+		W: width,
+		H: bottom - muzzle.Y,
+	}
+}
+
+// 进入阶段，重置该阶段所有攻击
+func (s *sceneMain) enterBossPhase(b *boss, phase int, now uint64) {
+	b.phase = phase
+	attacks := b.kind.phases[phase].Attacks
+	b.attacks = make([]bossAttack, len(attacks))
+	for i := range attacks {
+		b.attacks[i].def = &attacks[i]
+		b.attacks[i].lastTime = now
+		if attacks[i].Laser == nil {
+			b.attacks[i].emitter.pattern = s.patterns[attacks[i].Pattern]
+		}
+	}
+}
+
+// 生成Boss，从屏幕上方中间入场
+func (s *sceneMain) spawnBoss(kind *bossType) {
+	boss := kind.template
+	boss.kind = kind
+	boss.position.X = s.fieldWidth/2 - boss.width/2
+	boss.position.Y = -boss.height
+	boss.lastPosition = boss.position
+	boss.originX = boss.position.X
+	s.enterBossPhase(&boss, 0, s.enemyTime())
+	s.boss = &boss
+}
+
+func (s *sceneMain) updateBoss(deltaTime float32) {
+	b := s.boss
+	if b == nil {
+		return
+	}
+	b.lastPosition = b.position
+	currentTime := s.enemyTime()
+	if b.dying {
+		s.updateBossDeath(currentTime)
+		return
+	}
+
+	// 入场后左右摆动
+	if b.isEntering() {
+		b.position.Y = min(b.kind.enterY, b.position.Y+b.speed*deltaTime)
+		return
+	}
+	b.age += deltaTime
+	angle := 2 * math.Pi * float64(b.kind.swayFrequency*b.age)
+	b.position.X = b.originX + b.kind.swayAmplitude*float32(math.Sin(angle))
+
+	if b.currentHealth <= 0 {
+		b.dying = true
+		b.deathStartTime = currentTime
+		b.lastExplosionTime = 0
+		// 清除场上的敌人子弹
+		s.clearEnemyProjectiles()
+		return
+	}
+
+	// 生命值降到阈值后进入下一阶段
+	ratio := b.currentHealth / b.maxHealth
+	for i := len(b.kind.phases) - 1; i > b.phase; i-- {
+		if ratio <= b.kind.phases[i].HealthBelow {
+			s.enterBossPhase(b, i, currentTime)
+			break
+		}
+	}
+
+	// 撞上Boss受到伤害并被弹开
+	if s.hitPlayerCore(b.hitbox, b.position) {
+		center := sdl.FPoint{X: b.position.X + b.width/2, Y: b.position.Y + b.height/2}
+		s.damagePlayer(1, center)
+	}
+	if s.isDead {
+		return
+	}
+	for i := range b.attacks {
+		s.updateBossAttack(b, &b.attacks[i], currentTime)
+	}
+}
+
+func (s *sceneMain) updateBossAttack(b *boss, attack *bossAttack, currentTime uint64) {
+	def := attack.def
+	if def.Laser == nil {
+		s.updateEmitter(&attack.emitter, b.muzzle(), currentTime)
+		if currentTime-attack.lastTime >= def.CoolDownMs && !attack.emitter.busy() {
+			s.emit(&attack.emitter, b.muzzle(), currentTime)
+			attack.lastTime = currentTime
+		}
+		return
+	}
+
+	laser := def.Laser
+	switch attack.laserState {
+	case laserStateIdle:
+		if currentTime-attack.lastTime >= def.CoolDownMs {
+			attack.laserState = laserStateWarn
+			attack.laserTime = currentTime
+		}
+	case laserStateWarn:
+		if currentTime-attack.laserTime >= laser.WarnMs {
+			attack.laserState = laserStateFire
+			attack.laserTime = currentTime
+			attack.laserHit = false
+			s.playSound("enemy_shoot")
+		}
+	case laserStateFire:
+		// 每道激光最多造成一次伤害，从激光中心向两侧击退
+		rect := b.laserRect(laser.Width, s.fieldHeight)
+		if !attack.laserHit && s.player.core.overlapsRect(s.player.position, rect) {
+			from := sdl.FPoint{X: rect.X + rect.W/2, Y: s.player.position.Y + s.player.height/2}
+			attack.laserHit = s.damagePlayer(1, from)
+		}
+		if currentTime-attack.laserTime >= laser.DurationMs {
+			attack.laserState = laserStateIdle
+			attack.lastTime = currentTime
+		}
+	}
+}
+
+// 玩家子弹击中Boss，击中弱点造成全部伤害，其它部位按倍数减少，返回是否击中
+// 弱点不要求在碰撞盒内
+func (s *sceneMain) hitBoss(projectile entity) bool {
+	b := s.boss
+	if b == nil || b.dying {
+		return false
+	}
+	position := s.world.transforms.at(projectile).position
+	c := s.world.colliders.at(projectile)
+	weak := false
+	for i := range b.kind.weakPoints {
+		if c.hitbox.overlapsRect(position, b.weakPointRect(&b.kind.weakPoints[i])) {
+			weak = true
+			break
+		}
+	}
+	if !weak && !c.hitbox.overlaps(position, b.hitbox, b.position) {
+		return false
+	}
+	s.playSound("hit")
+	// 入场时无敌
+	if b.isEntering() {
+		return true
+	}
+	scale := b.kind.bodyDamageScale
+	if weak {
+		scale = 1
+		b.weakHitTime = s.currentTime()
+	}
+	b.currentHealth -= float32(c.damage) * scale
+	return true
+}
+
+// 死亡动画，在Boss身上随机位置连续爆炸，最后一起爆炸后移除
+func (s *sceneMain) updateBossDeath(currentTime uint64) {
+	b := s.boss
+	if currentTime-b.deathStartTime >= bossDeathMs {
+		for i := 0; i < bossFinalExplosionCount; i++ {
+			s.bossExplode(b)
+		}
+		s.playSound("player_explode")
+		s.score += b.kind.score
+		s.boss = nil
+		return
+	}
+	if b.lastExplosionTime == 0 || currentTime-b.lastExplosionTime >= bossDeathExplosionMs {
+		s.bossExplode(b)
+		s.playSound("enemy_explode")
+		b.lastExplosionTime = max(currentTime, 1)
+	}
+}
+
+// 在Boss身上随机位置产生一个爆炸
+func (s *sceneMain) bossExplode(b *boss) {
+	x := b.position.X + s.rand.Float32()*b.width
+	y := b.position.Y + s.rand.Float32()*b.height
+	s.addExplosion(sdl.FPoint{X: x, Y: y})
+}
+
+func (s *sceneMain) renderBoss() {
+	b := s.boss
+	if b == nil {
+		return
+	}
+	renderer := GetInstance().sdlRenderer
+	currentTime := s.currentTime()
+
+	// 激光，预警时显示细线
+	if !b.dying {
+		for i := range b.attacks {
+			attack := &b.attacks[i]
+			switch attack.laserState {
+			case laserStateWarn:
+				rect := b.laserRect(2, s.fieldHeight)
+				GetInstance().renderFillRect(rect, sdl.Color{R: 255, G: 60, B: 60, A: 160})
+			case laserStateFire:
+				rect := b.laserRect(attack.def.Laser.Width, s.fieldHeight)
+				GetInstance().renderFillRect(rect, sdl.Color{R: 255, G: 80, B: 80, A: 200})
+				core := b.laserRect(attack.def.Laser.Width/3, s.fieldHeight)
+				GetInstance().renderFillRect(core, sdl.Color{R: 255, G: 240, B: 240, A: 255})
+			}
+		}
+	}
+
+	position := GetInstance().interpolate(b.lastPosition, b.position)
+	ds := sdl.FRect{X: position.X, Y: position.Y, W: b.width, H: b.height}
+	// 弱点被击中或者死亡时闪红
+	flash := b.dying && (currentTime/100)%2 == 0
+	flash = flash || (b.weakHitTime != 0 && currentTime-b.weakHitTime < bossHitFlashMs)
+	if flash {
+		sdl.SetTextureColorMod(b.texture, 255, 90, 90)
+	}
+	sdl.RenderTexture(renderer, b.texture, nil, &ds)
+	if flash {
+		sdl.SetTextureColorMod(b.texture, 255, 255, 255)
+	}
+}
+
+// 渲染Boss血条，标出阶段阈值
+func (s *sceneMain) renderBossHealth() {
+	b := s.boss
+	if b == nil {
+		return
+	}
+	width := float32(GetInstance().windowWidth) * 0.6
+	bar := sdl.FRect{
+		X: (float32(GetInstance().windowWidth) - width) / 2,
+		Y: 56,
+		W: width,
+		H: 10,
+	}
+	GetInstance().renderFillRect(bar, sdl.Color{R: 60, G: 60, B: 60, A: 255})
+	fill := bar
+	fill.W = bar.W * max(0, b.currentHealth) / b.maxHealth
+	GetInstance().renderFillRect(fill, sdl.Color{R: 220, G: 40, B: 40, A: 255})
+	for i := 1; i < len(b.kind.phases); i++ {
+		mark := sdl.FRect{
+			X: bar.X + bar.W*b.kind.phases[i].HealthBelow - 1,
+			Y: bar.Y,
+			W: 2,
+			H: bar.H,
+		}
+		GetInstance().renderFillRect(mark, sdl.Color{R: 255, G: 255, B: 255, A: 255})
+	}
+}
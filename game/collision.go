@@ -0,0 +1,203 @@
+package game
+
+import (
+	"math"
+
+	"github.com/SunshineZzzz/purego-sdl3/sdl"
+)
+
+// 调试显示碰撞盒时圆形的分段数
+const circleSegments = 16
+
+// 碰撞形状类型
+type shapeKind int32
+
+const (
+	// 矩形
+	shapeRect shapeKind = iota
+	// 圆形
+	shapeCircle
+)
+
+var shapeKinds = map[string]shapeKind{
+	"rect":   shapeRect,
+	"circle": shapeCircle,
+}
+
+// 碰撞形状，坐标相对实体左上角
+type shape struct {
+	kind shapeKind
+	// 矩形（shapeRect）
+	rect sdl.FRect
+	// 圆心（shapeCircle）
+	center sdl.FPoint
+	// 半径（shapeCircle）
+	radius float32
+}
+
+// 碰撞盒，由多个形状组成，任意形状相交即碰撞
+type hitbox []shape
+
+// 由定义创建碰撞盒，定义相对实体尺寸，没有定义时使用整个实体矩形
+func newHitbox(defs []hitboxDef, width float32, height float32) hitbox {
+	if len(defs) == 0 {
+		return hitbox{{kind: shapeRect, rect: sdl.FRect{X: 0, Y: 0, W: width, H: height}}}
+	}
+	h := make(hitbox, 0, len(defs))
+	for _, def := range defs {
+		switch shapeKinds[def.Type] {
+		case shapeRect:
+			h = append(h, shape{
+				kind: shapeRect,
+				rect: sdl.FRect{X: def.X * width, Y: def.Y * height, W: def.W * width, H: def.H * height},
+			})
+		case shapeCircle:
+			h = append(h, shape{
+				kind:   shapeCircle,
+				center: sdl.FPoint{X: def.X * width, Y: def.Y * height},
+				radius: def.R * width,
+			})
+		}
+	}
+	return h
+}
+
+// 移动到实体位置后的形状
+func (s shape) at(position sdl.FPoint) shape {
+	s.rect.X += position.X
+	s.rect.Y += position.Y
+	s.center.X += position.X
+	s.center.Y += position.Y
+	return s
+}
+
+// 两个形状是否相交
+func shapesOverlap(a shape, b shape) bool {
+	switch {
+	case a.kind == shapeRect && b.kind == shapeRect:
+		return sdl.HasRectIntersectionFloat(a.rect, b.rect)
+	case a.kind == shapeCircle && b.kind == shapeCircle:
+		return circleCircle(a.center, a.radius, b.center, b.radius)
+	case a.kind == shapeCircle:
+		return circleRect(a.center, a.radius, b.rect)
+	default:
+		return circleRect(b.center, b.radius, a.rect)
+	}
+}
+
+// 圆和圆是否相交
+func circleCircle(centerA sdl.FPoint, radiusA float32, centerB sdl.FPoint, radiusB float32) bool {
+	x := centerA.X - centerB.X
+	y := centerA.Y - centerB.Y
+	radius := radiusA + radiusB
+	return x*x+y*y < radius*radius
+}
+
+// 圆和矩形是否相交，找到矩形上离圆心最近的点
+func circleRect(center sdl.FPoint, radius float32, rect sdl.FRect) bool {
+	x := center.X - max(rect.X, min(center.X, rect.X+rect.W))
+	y := center.Y - max(rect.Y, min(center.Y, rect.Y+rect.H))
+	return x*x+y*y < radius*radius
+}
+
+// 位于position的碰撞盒是否和另一个碰撞盒相交
+func (h hitbox) overlaps(position sdl.FPoint, other hitbox, otherPosition sdl.FPoint) bool {
+	for _, a := range h {
+		a = a.at(position)
+		for _, b := range other {
+			if shapesOverlap(a, b.at(otherPosition)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// 位于position的碰撞盒是否和矩形相交，矩形为世界坐标
+func (h hitbox) overlapsRect(position sdl.FPoint, rect sdl.FRect) bool {
+	other := shape{kind: shapeRect, rect: rect}
+	for _, a := range h {
+		if shapesOverlap(a.at(position), other) {
+			return true
+		}
+	}
+	return false
+}
+
+// 位于position的碰撞盒的包围矩形，用于碰撞网格
+func (h hitbox) bounds(position sdl.FPoint) sdl.FRect {
+	minX, minY := float32(math.MaxFloat32), float32(math.MaxFloat32)
+	maxX, maxY := -float32(math.MaxFloat32), -float32(math.MaxFloat32)
+	for _, s := range h {
+		s = s.at(position)
+		rect := s.rect
+		if s.kind == shapeCircle {
+			rect = sdl.FRect{X: s.center.X - s.radius, Y: s.center.Y - s.radius, W: 2 * s.radius, H: 2 * s.radius}
+		}
+		minX = min(minX, rect.X)
+		minY = min(minY, rect.Y)
+		maxX = max(maxX, rect.X+rect.W)
+		maxY = max(maxY, rect.Y+rect.H)
+	}
+	return sdl.FRect{X: minX, Y: minY, W: maxX - minX, H: maxY - minY}
+}
+
+// 调试渲染碰撞盒轮廓
+func (h hitbox) render(position sdl.FPoint, color sdl.Color) {
+	renderer := GetInstance().sdlRenderer
+	sdl.SetRenderDrawColor(renderer, color.R, color.G, color.B, color.A)
+	for _, s := range h {
+		s = s.at(position)
+		switch s.kind {
+		case shapeRect:
+			sdl.RenderRect(renderer, &s.rect)
+		case shapeCircle:
+			// 半径太小时画成一个点
+			if s.radius < 1 {
+				sdl.RenderPoint(renderer, s.center.X, s.center.Y)
+				continue
+			}
+			step := 2 * math.Pi / circleSegments
+			for i := 0; i < circleSegments; i++ {
+				sin0, cos0 := math.Sincos(step * float64(i))
+				sin1, cos1 := math.Sincos(step * float64(i+1))
+				sdl.RenderLine(renderer,
+					s.center.X+s.radius*float32(cos0), s.center.Y+s.radius*float32(sin0),
+					s.center.X+s.radius*float32(cos1), s.center.Y+s.radius*float32(sin1))
+			}
+		}
+	}
+	sdl.SetRenderDrawColor(renderer, 0, 0, 0, 255)
+}
+
+// 调试渲染场上所有碰撞盒
+func (s *sceneMain) renderHitboxes() {
+	g := GetInstance()
+	red := sdl.Color{R: 255, G: 60, B: 60, A: 255}
+	yellow := sdl.Color{R: 255, G: 220, B: 0, A: 255}
+	green := sdl.Color{R: 60, G: 255, B: 60, A: 255}
+	cyan := sdl.Color{R: 0, G: 220, B: 255, A: 255}
+	// 按阵营区分颜色
+	colors := [...]sdl.Color{teamPlayer: cyan, teamEnemy: red, teamNeutral: green}
+	w := s.world
+	for e := range w.query(componentTransform | componentCollider | componentTeam) {
+		t := w.transforms.at(e)
+		w.colliders.at(e).hitbox.render(g.interpolate(t.lastPosition, t.position), colors[*w.teams.at(e)])
+	}
+	if b := s.boss; b != nil {
+		position := g.interpolate(b.lastPosition, b.position)
+		b.hitbox.render(position, red)
+		for _, point := range b.kind.weakPoints {
+			weak := shape{
+				kind: shapeRect,
+				rect: sdl.FRect{X: point.X * b.width, Y: point.Y * b.height, W: point.W * b.width, H: point.H * b.height},
+			}
+			hitbox{weak}.render(position, yellow)
+		}
+	}
+	if !s.isDead {
+		position := g.interpolate(s.player.lastPosition, s.player.position)
+		s.player.hitbox.render(position, green)
+		s.player.core.render(position, sdl.Color{R: 255, G: 255, B: 255, A: 255})
+	}
+}
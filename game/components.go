@@ -0,0 +1,207 @@
+package game
+
+import (
+	"github.com/SunshineZzzz/purego-sdl3/sdl"
+)
+
+// 子弹超出屏幕外边界多远后移除
+const projectileMargin = 32
+
+// 位置和尺寸
+type transform struct {
+	// 位置，左上角
+	position sdl.FPoint
+	// 上一逻辑帧的位置，用于渲染插值
+	lastPosition sdl.FPoint
+	// 宽度
+	width float32
+	// 高度
+	height float32
+}
+
+// 中心
+func (t *transform) center() sdl.FPoint {
+	return sdl.FPoint{X: t.position.X + t.width/2, Y: t.position.Y + t.height/2}
+}
+
+// 速度
+type velocity struct {
+	// 方向
+	direction sdl.FPoint
+	// 速度
+	speed float32
+}
+
+// 渲染层，从下到上
+type renderLayer int32
+
+const (
+	// 玩家子弹
+	layerPlayerProjectile renderLayer = iota
+	// 敌人子弹
+	layerEnemyProjectile
+	// 敌人
+	layerEnemy
+	// 物品
+	layerItem
+	// 爆炸
+	layerExplosion
+)
+
+// 精灵
+type sprite struct {
+	// 纹理
+	texture *sdl.Texture
+	// 渲染层
+	layer renderLayer
+	// 是否按速度方向旋转
+	rotate bool
+	// 按速度方向旋转时附加的角度，度
+	angleOffset float64
+	// 序列帧动画的帧数，帧在纹理中横向排列，0表示不是动画
+	frames float32
+	// 动画帧率，1秒多少张图片，frames/fps = 播放时间
+	fps uint32
+	// 动画开始时间
+	startTime uint64
+}
+
+// 生命值
+type health struct {
+	// 当前生命值，耗尽时死亡
+	current int32
+}
+
+// 碰撞体
+type collider struct {
+	// 碰撞盒
+	hitbox hitbox
+	// 碰撞时造成的伤害
+	damage int32
+	// 剩余可以穿透的目标数量
+	pierce int32
+	// 已经击中的目标，穿透时对同一个目标只造成一次伤害
+	hits []entity
+}
+
+// 生命周期
+type lifetime struct {
+	// 结束时间，毫秒，0表示不限时
+	endTime uint64
+	// 是否在离开屏幕后移除
+	offscreen bool
+	// 超出屏幕边界多远才算离开屏幕
+	margin float32
+	// 是否允许停留在屏幕上方，从上方入场的实体使用，完全进入屏幕后清除
+	allowAbove bool
+}
+
+// 阵营，不同阵营的实体之间才会碰撞
+type team int32
+
+const (
+	// 玩家和玩家子弹
+	teamPlayer team = iota
+	// 敌人和敌人子弹
+	teamEnemy
+	// 中立，例如物品，只和玩家碰撞
+	teamNeutral
+)
+
+// 敌人子弹的行为状态
+type steering struct {
+	// 子弹行为，同一弹幕的子弹共享
+	behavior *bulletBehavior
+	// 发射后的时间，秒
+	age float32
+	// 发射速度，延迟启动时启动后才使用
+	speed float32
+}
+
+// 追踪敌人
+type homing struct {
+	// 最大转向速度，弧度每秒
+	rate float32
+}
+
+// 敌人的移动和射击
+type enemyAI struct {
+	// 敌人类型
+	kind *enemyType
+	// 存活时间，秒
+	age float32
+	// 生成位置，摆动以此为中心
+	origin sdl.FPoint
+	// 移动方向
+	direction sdl.FPoint
+	// 行为阶段
+	phase int32
+	// 进入当前阶段的时间
+	phaseStartTime uint64
+	// 所属波次
+	wave int32
+	// 射击使用的发射器
+	emitter emitter
+	// 冷却时间
+	coolDown uint64
+	// 上次射击时间
+	lastShootTime uint64
+}
+
+// 可拾取的物品
+type pickup struct {
+	// 物品类型
+	itemType itemType
+	// 效果持续时间，毫秒
+	duration uint64
+	// 效果结束前闪烁提示的时间，毫秒
+	warn uint64
+	// 时间缩放（itemTypeTime）
+	timeScale float32
+	// 武器（itemTypeWeapon）
+	weapon *weapon
+	// 剩余弹跳次数
+	bounceCount int32
+}
+
+// 预制体，同类实体共享的组件数据，生成实体时复制
+type prefab struct {
+	// 精灵
+	sprite sprite
+	// 宽度
+	width float32
+	// 高度
+	height float32
+	// 阵营
+	team team
+	// 碰撞盒，为空时没有碰撞体
+	hitbox hitbox
+	// 碰撞时造成的伤害
+	damage int32
+	// 生命值，0表示没有生命值
+	health int32
+	// 速度
+	speed float32
+	// 生命周期
+	lifetime lifetime
+}
+
+// 由预制体在position生成实体，其它组件由调用者加入
+func (s *sceneMain) spawn(p *prefab, position sdl.FPoint) entity {
+	w := s.world
+	e := w.create()
+	*w.transforms.add(w, e) = transform{position: position, lastPosition: position, width: p.width, height: p.height}
+	*w.sprites.add(w, e) = p.sprite
+	*w.teams.add(w, e) = p.team
+	*w.lifetimes.add(w, e) = p.lifetime
+	if p.hitbox != nil {
+		c := w.colliders.add(w, e)
+		// 保留上次使用的击中列表，避免重新分配
+		hits := c.hits[:0]
+		*c = collider{hitbox: p.hitbox, damage: p.damage, hits: hits}
+	}
+	if p.health > 0 {
+		*w.healths.add(w, e) = health{current: p.health}
+	}
+	return e
+}
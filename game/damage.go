@@ -0,0 +1,63 @@
+package game
+
+import (
+	"github.com/SunshineZzzz/purego-sdl3/sdl"
+)
+
+// 击退速度每秒衰减的比例
+const knockbackDecay = 8
+
+// 玩家受到伤害，所有伤害来源（子弹、碰撞、激光等）都必须通过这里
+// from为伤害来源的中心，用于计算击退方向
+// 死亡或者无敌时忽略，返回false，伤害来源应该保留，例如子弹穿过玩家
+// 否则被击退，护盾有效时抵挡伤害，返回true
+// 没有护盾时扣血，武器降一级，进入受伤无敌时间并闪红，返回true
+func (s *sceneMain) damagePlayer(damage int32, from sdl.FPoint) bool {
+	currentTime := s.currentTime()
+	if s.isDead || s.player.invincible.isActive(currentTime) {
+		return false
+	}
+	s.playSound("hit")
+	center := sdl.FPoint{X: s.player.position.X + s.player.width/2, Y: s.player.position.Y + s.player.height/2}
+	direction := directionTo(from, center, sdl.FPoint{X: 0, Y: 1})
+	s.player.knockback = sdl.FPoint{X: direction.X * s.player.knockbackSpeed, Y: direction.Y * s.player.knockbackSpeed}
+	if s.isShielded() {
+		return true
+	}
+	s.player.currentHealth -= damage
+	s.setWeapon(s.player.weapon, s.player.weaponLevel-1)
+	s.player.invincible.start(currentTime, s.player.hitInvincibleMs, s.player.hitInvincibleMs)
+	s.player.hitTime = currentTime
+	return true
+}
+
+// 应用击退速度并衰减
+func (s *sceneMain) updateKnockback(deltaTime float32) {
+	s.player.position.X += s.player.knockback.X * deltaTime
+	s.player.position.Y += s.player.knockback.Y * deltaTime
+	decay := max(0, 1-knockbackDecay*deltaTime)
+	s.player.knockback.X *= decay
+	s.player.knockback.Y *= decay
+}
+
+// 玩家刚受伤时是否闪红
+func (s *sceneMain) isHitFlashing() bool {
+	return s.player.hitTime != 0 && s.currentTime()-s.player.hitTime < s.player.hitFlashMs
+}
+
+// 受伤时屏幕边缘闪红
+func (s *sceneMain) renderHitFlash() {
+	if !s.isHitFlashing() {
+		return
+	}
+	elapsed := s.currentTime() - s.player.hitTime
+	alpha := uint8(120 * (s.player.hitFlashMs - elapsed) / s.player.hitFlashMs)
+	color := sdl.Color{R: 255, G: 0, B: 0, A: alpha}
+	width := float32(GetInstance().windowWidth)
+	height := float32(GetInstance().windowHeight)
+	border := float32(24)
+	GetInstance().renderFillRect(sdl.FRect{X: 0, Y: 0, W: width, H: border}, color)
+	GetInstance().renderFillRect(sdl.FRect{X: 0, Y: height - border, W: width, H: border}, color)
+	GetInstance().renderFillRect(sdl.FRect{X: 0, Y: border, W: border, H: height - 2*border}, color)
+	GetInstance().renderFillRect(sdl.FRect{X: width - border, Y: border, W: border, H: height - 2*border}, color)
+}
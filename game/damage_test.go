@@ -0,0 +1,66 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/SunshineZzzz/purego-sdl3/sdl"
+)
+
+// 创建无头模式下初始化好的主场景，资源从仓库根目录读取
+func newHeadlessScene(t testing.TB) *sceneMain {
+	t.Helper()
+	t.Chdir("..")
+	g := GetInstance()
+	g.headless = true
+	g.deltaTime = float32(g.tickTime) / 1e9
+	g.SetSeed(1)
+	s := &sceneMain{input: &scriptedInput{}}
+	if err := s.init(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.clean)
+	return s
+}
+
+// 在玩家判定点上生成一个敌人
+func addEnemyOnPlayer(s *sceneMain) entity {
+	kind := s.enemyTypes[0]
+	center := sdl.FPoint{X: s.player.position.X + s.player.width/2, Y: s.player.position.Y + s.player.height/2}
+	return s.addEnemy(kind, sdl.FPoint{X: center.X - kind.prefab.width/2, Y: center.Y - kind.prefab.height/2})
+}
+
+// 敌人撞上玩家时双方都受伤
+func TestEnemyRamDamagesPlayer(t *testing.T) {
+	s := newHeadlessScene(t)
+	e := addEnemyOnPlayer(s)
+	health := s.player.currentHealth
+
+	s.collisionSystem()
+	if s.player.currentHealth != health-1 {
+		t.Errorf("player health %v, want %v", s.player.currentHealth, health-1)
+	}
+	if h := s.world.healths.get(s.world, e); h == nil || h.current != 0 {
+		t.Error("enemy not killed by ramming")
+	}
+}
+
+// 无敌时间内敌人穿过玩家，不会被撞毁，也不会得分
+func TestEnemyRamDuringInvincibility(t *testing.T) {
+	s := newHeadlessScene(t)
+	s.player.invincible.start(s.currentTime(), 1000, 1000)
+	e := addEnemyOnPlayer(s)
+	health := s.player.currentHealth
+	enemyHealth := s.world.healths.at(e).current
+
+	s.collisionSystem()
+	s.damageSystem()
+	if s.player.currentHealth != health {
+		t.Errorf("invincible player damaged, health %v, want %v", s.player.currentHealth, health)
+	}
+	if h := s.world.healths.get(s.world, e); h == nil || h.current != enemyHealth {
+		t.Error("enemy killed by ramming an invincible player")
+	}
+	if s.score != 0 {
+		t.Errorf("score %v awarded during invincibility", s.score)
+	}
+}
@@ -0,0 +1,144 @@
+package game
+
+import "iter"
+
+// 实体，用对象池的句柄表示，实体销毁后旧句柄失效
+type entity = handle
+
+// 组件掩码，每种组件占一位
+type componentMask uint32
+
+const (
+	// 位置和尺寸
+	componentTransform componentMask = 1 << iota
+	// 速度
+	componentVelocity
+	// 精灵
+	componentSprite
+	// 生命值
+	componentHealth
+	// 碰撞体
+	componentCollider
+	// 生命周期
+	componentLifetime
+	// 阵营
+	componentTeam
+	// 敌人子弹的行为
+	componentSteering
+	// 追踪敌人
+	componentHoming
+	// 敌人的移动和射击
+	componentEnemyAI
+	// 可拾取的物品
+	componentPickup
+)
+
+// 组件存储，按实体槽位下标分块存放，组件地址在世界的生命周期内不变
+type componentStore[T any] struct {
+	// 组件类型
+	mask componentMask
+	// 存储块
+	chunks [][]T
+}
+
+// 实体槽位中的组件，不检查实体是否拥有该组件
+func (c *componentStore[T]) at(e entity) *T {
+	return &c.chunks[e.index/poolChunkSize][e.index%poolChunkSize]
+}
+
+// 给实体加入组件，组件保留槽位上次使用时的内容，由调用者覆盖
+func (c *componentStore[T]) add(w *world, e entity) *T {
+	for int(e.index) >= len(c.chunks)*poolChunkSize {
+		c.chunks = append(c.chunks, make([]T, poolChunkSize))
+	}
+	*w.entities.get(e) |= c.mask
+	return c.at(e)
+}
+
+// 实体的组件，实体已经销毁或者没有该组件时返回nil
+func (c *componentStore[T]) get(w *world, e entity) *T {
+	if !w.has(e, c.mask) {
+		return nil
+	}
+	return c.at(e)
+}
+
+// 实体世界，保存所有实体和组件
+type world struct {
+	// 实体，值为实体拥有的组件
+	entities *pool[componentMask]
+	// 位置和尺寸
+	transforms componentStore[transform]
+	// 速度
+	velocities componentStore[velocity]
+	// 精灵
+	sprites componentStore[sprite]
+	// 生命值
+	healths componentStore[health]
+	// 碰撞体
+	colliders componentStore[collider]
+	// 生命周期
+	lifetimes componentStore[lifetime]
+	// 阵营
+	teams componentStore[team]
+	// 敌人子弹的行为
+	steerings componentStore[steering]
+	// 追踪敌人
+	homings componentStore[homing]
+	// 敌人的移动和射击
+	enemyAIs componentStore[enemyAI]
+	// 可拾取的物品
+	pickups componentStore[pickup]
+}
+
+// 创建实体世界
+func newWorld() *world {
+	return &world{
+		entities:   newPool[componentMask](),
+		transforms: componentStore[transform]{mask: componentTransform},
+		velocities: componentStore[velocity]{mask: componentVelocity},
+		sprites:    componentStore[sprite]{mask: componentSprite},
+		healths:    componentStore[health]{mask: componentHealth},
+		colliders:  componentStore[collider]{mask: componentCollider},
+		lifetimes:  componentStore[lifetime]{mask: componentLifetime},
+		teams:      componentStore[team]{mask: componentTeam},
+		steerings:  componentStore[steering]{mask: componentSteering},
+		homings:    componentStore[homing]{mask: componentHoming},
+		enemyAIs:   componentStore[enemyAI]{mask: componentEnemyAI},
+		pickups:    componentStore[pickup]{mask: componentPickup},
+	}
+}
+
+// 创建一个没有组件的实体
+func (w *world) create() entity {
+	e, mask := w.entities.alloc()
+	*mask = 0
+	return e
+}
+
+// 销毁实体，句柄失效时返回false
+func (w *world) destroy(e entity) bool {
+	return w.entities.release(e)
+}
+
+// 实体是否存活并且拥有mask中的所有组件
+func (w *world) has(e entity, mask componentMask) bool {
+	components := w.entities.get(e)
+	return components != nil && *components&mask == mask
+}
+
+// 销毁所有实体
+func (w *world) clear() {
+	w.entities.clear()
+}
+
+// 按创建顺序遍历拥有mask中所有组件的实体，遍历中可以创建和销毁实体，新创建的实体不会被遍历到
+func (w *world) query(mask componentMask) iter.Seq[entity] {
+	return func(yield func(entity) bool) {
+		for e, components := range w.entities.all() {
+			if *components&mask == mask && !yield(e) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package game
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/SunshineZzzz/purego-sdl3/sdl"
+)
+
+// 敌人移动方式
+type movementKind int32
+
+const (
+	// 直线下落
+	movementStraight movementKind = iota
+	// 下落时左右正弦摆动
+	movementSine
+	// 下落到一定高度后朝玩家俯冲
+	movementDive
+	// 缓慢下落并左右来回移动
+	movementStrafe
+	// 下落到一定高度后悬停射击，然后离开
+	movementStopAndShoot
+	// 编队生成并整体摆动
+	movementFormation
+)
+
+var movementKinds = map[string]movementKind{
+	"straight":     movementStraight,
+	"sine":         movementSine,
+	"dive":         movementDive,
+	"strafe":       movementStrafe,
+	"stopAndShoot": movementStopAndShoot,
+	"formation":    movementFormation,
+}
+
+// 敌人行为阶段
+const (
+	// 入场
+	enemyPhaseEnter int32 = iota
+	// 俯冲或者悬停
+	enemyPhaseAction
+	// 离场
+	enemyPhaseLeave
+)
+
+// 敌人类型
+type enemyType struct {
+	// 类型名字
+	name string
+	// 敌人预制体
+	prefab prefab
+	// 射击冷却时间，毫秒
+	coolDown uint64
+	// 击毁得分
+	score uint32
+	// 随机生成的权重
+	weight float32
+	// 移动方式
+	movement movementKind
+	// 移动参数
+	params movementDef
+	// 射击使用的弹幕
+	pattern *bulletPattern
+}
+
+// 按权重随机选择，返回下标
+func pickWeighted(r *rand.Rand, weights []float32) int {
+	total := float32(0)
+	for _, weight := range weights {
+		total += weight
+	}
+	value := r.Float32() * total
+	for i, weight := range weights {
+		if value < weight {
+			return i
+		}
+		value -= weight
+	}
+	return len(weights) - 1
+}
+
+// 按移动方式更新敌人位置
+func (s *sceneMain) moveEnemy(t *transform, ai *enemyAI, deltaTime float32) {
+	kind := ai.kind
+	ai.age += deltaTime
+	switch kind.movement {
+	case movementStraight:
+		t.position.Y += kind.prefab.speed * deltaTime
+	case movementSine, movementFormation:
+		t.position.Y += kind.prefab.speed * deltaTime
+		angle := 2 * math.Pi * float64(kind.params.Frequency*ai.age)
+		t.position.X = ai.origin.X + kind.params.Amplitude*float32(math.Sin(angle))
+	case movementDive:
+		if ai.phase == enemyPhaseEnter {
+			t.position.Y += kind.prefab.speed * deltaTime
+			if t.position.Y >= kind.params.TriggerY {
+				// 锁定玩家当前位置俯冲
+				ai.phase = enemyPhaseAction
+				ai.direction = s.getDirection(t)
+			}
+			return
+		}
+		t.position.X += ai.direction.X * kind.params.DiveSpeed * deltaTime
+		t.position.Y += ai.direction.Y * kind.params.DiveSpeed * deltaTime
+	case movementStrafe:
+		t.position.Y += kind.prefab.speed * deltaTime
+		t.position.X += ai.direction.X * kind.params.StrafeSpeed * deltaTime
+		// 碰到屏幕边缘反向
+		if t.position.X < 0 {
+			t.position.X = 0
+			ai.direction.X = 1
+		}
+		if t.position.X > s.fieldWidth-t.width {
+			t.position.X = s.fieldWidth - t.width
+			ai.direction.X = -1
+		}
+	case movementStopAndShoot:
+		switch ai.phase {
+		case enemyPhaseEnter:
+			t.position.Y += kind.prefab.speed * deltaTime
+			if t.position.Y >= kind.params.StopY {
+				t.position.Y = kind.params.StopY
+				ai.phase = enemyPhaseAction
+				ai.phaseStartTime = s.enemyTime()
+				ai.coolDown = kind.params.HoldCoolDownMs
+			}
+		case enemyPhaseAction:
+			if s.enemyTime()-ai.phaseStartTime > kind.params.HoldMs {
+				ai.phase = enemyPhaseLeave
+				ai.coolDown = kind.coolDown
+			}
+		case enemyPhaseLeave:
+			t.position.Y += kind.prefab.speed * deltaTime
+		}
+	}
+}
+
+// 在随机位置生成一个敌人类型的敌人，编队类型一次生成一组
+func (s *sceneMain) spawnEnemyOfType(kind *enemyType) {
+	margin := float32(0)
+	if kind.movement == movementSine || kind.movement == movementFormation {
+		margin = kind.params.Amplitude
+	}
+	x := margin + s.rand.Float32()*max(0, s.fieldWidth-kind.groupWidth()-2*margin)
+	s.spawnEnemyAt(kind, sdl.FPoint{X: x, Y: -kind.prefab.height})
+}
+
+// 在指定位置生成一个敌人类型的敌人，position为整组的左上角，编队类型一次生成一组
+func (s *sceneMain) spawnEnemyAt(kind *enemyType, position sdl.FPoint) {
+	if kind.movement != movementFormation {
+		s.addEnemy(kind, position)
+		return
+	}
+	// V字编队，中间的敌人在最前面
+	count := kind.params.Count
+	spacing := kind.params.Spacing
+	for i := int32(0); i < count; i++ {
+		offset := float32(math.Abs(float64(i) - float64(count-1)/2))
+		x := position.X + float32(i)*spacing
+		y := position.Y - offset*spacing/2
+		s.addEnemy(kind, sdl.FPoint{X: x, Y: y})
+	}
+}
+
+// 一次生成的整组敌人的宽度，编队类型为整个编队的宽度
+func (k *enemyType) groupWidth() float32 {
+	if k.movement == movementFormation {
+		return float32(k.params.Count-1)*k.params.Spacing + k.prefab.width
+	}
+	return k.prefab.width
+}
@@ -0,0 +1,752 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// 实体定义文件
+const entityDefsPath = "assets/data/entities.json"
+
+// 碰撞形状定义，坐标和尺寸相对纹理尺寸，半径相对纹理宽度
+type hitboxDef struct {
+	// 形状：rect矩形，circle圆形
+	Type string `json:"type"`
+	// 左上角（rect）或者圆心（circle）
+	X float32 `json:"x"`
+	Y float32 `json:"y"`
+	// 宽高（rect）
+	W float32 `json:"w"`
+	H float32 `json:"h"`
+	// 半径（circle）
+	R float32 `json:"r"`
+}
+
+// 玩家定义
+type playerDef struct {
+	// 纹理路径
+	Texture string `json:"texture"`
+	// 纹理尺寸缩小倍数
+	ScaleDivisor float32 `json:"scaleDivisor"`
+	// 速度
+	Speed float32 `json:"speed"`
+	// 低速模式的速度
+	FocusSpeed float32 `json:"focusSpeed"`
+	// 生命值
+	Health int32 `json:"health"`
+	// 拾取物品的碰撞盒，为空时使用整个纹理
+	Hitbox []hitboxDef `json:"hitbox"`
+	// 受到伤害的判定点，低速模式时显示
+	Core []hitboxDef `json:"core"`
+	// 初始武器名字
+	Weapon string `json:"weapon"`
+	// 初始武器在weapons中的下标，校验时由名字解析
+	weaponIndex int
+	// 初始炸弹数量
+	Bombs int32 `json:"bombs"`
+	// 炸弹数量上限
+	MaxBombs int32 `json:"maxBombs"`
+	// 受伤后的无敌时间，毫秒
+	HitInvincibleMs uint64 `json:"hitInvincibleMs"`
+	// 受伤后闪红的时间，毫秒
+	HitFlashMs uint64 `json:"hitFlashMs"`
+	// 受伤时的击退速度，0表示不击退
+	Knockback float32 `json:"knockback"`
+}
+
+// 炸弹定义
+type bombDef struct {
+	// UI图标纹理路径
+	Icon string `json:"icon"`
+	// 对每个敌人的伤害
+	Damage int32 `json:"damage"`
+	// 对Boss的伤害
+	BossDamage float32 `json:"bossDamage"`
+	// 使用后的无敌时间，毫秒
+	InvincibleMs uint64 `json:"invincibleMs"`
+	// 全屏随机爆炸的数量
+	ExplosionCount int32 `json:"explosionCount"`
+}
+
+// 武器等级定义
+type weaponLevelDef struct {
+	// 子弹纹理路径
+	Texture string `json:"texture"`
+	// 纹理尺寸缩小倍数
+	ScaleDivisor float32 `json:"scaleDivisor"`
+	// 子弹速度
+	Speed float32 `json:"speed"`
+	// 子弹伤害
+	Damage int32 `json:"damage"`
+	// 射击冷却时间，毫秒
+	CoolDownMs uint64 `json:"coolDownMs"`
+	// 每次射击的子弹数量
+	Count int32 `json:"count"`
+	// 扇形角度，度
+	SpreadDeg float32 `json:"spreadDeg"`
+	// 并排子弹的水平间距，像素
+	Spacing float32 `json:"spacing"`
+	// 追踪敌人的最大转向速度，度每秒，0表示不追踪
+	HomingDegPerSec float32 `json:"homingDegPerSec"`
+	// 可以穿透的敌人数量
+	Pierce int32 `json:"pierce"`
+	// 碰撞盒，为空时使用整个纹理
+	Hitbox []hitboxDef `json:"hitbox"`
+}
+
+// 武器定义
+type weaponDef struct {
+	// 名字
+	Name string `json:"name"`
+	// 等级列表，从低到高
+	Levels []weaponLevelDef `json:"levels"`
+}
+
+// 子弹定义
+type projectileDef struct {
+	// 纹理路径
+	Texture string `json:"texture"`
+	// 纹理尺寸缩小倍数
+	ScaleDivisor float32 `json:"scaleDivisor"`
+	// 速度
+	Speed float32 `json:"speed"`
+	// 伤害
+	Damage int32 `json:"damage"`
+	// 碰撞盒，为空时使用整个纹理
+	Hitbox []hitboxDef `json:"hitbox"`
+}
+
+// 敌人移动方式定义，不同移动方式使用不同的参数
+type movementDef struct {
+	// 移动方式：straight直线下落，sine正弦摆动，dive俯冲玩家，strafe左右扫射，stopAndShoot悬停射击，formation编队飞行
+	Type string `json:"type"`
+	// 摆动幅度，像素（sine、formation）
+	Amplitude float32 `json:"amplitude"`
+	// 摆动频率，每秒次数（sine、formation）
+	Frequency float32 `json:"frequency"`
+	// 开始俯冲的高度（dive）
+	TriggerY float32 `json:"triggerY"`
+	// 俯冲速度（dive）
+	DiveSpeed float32 `json:"diveSpeed"`
+	// 水平速度（strafe）
+	StrafeSpeed float32 `json:"strafeSpeed"`
+	// 悬停高度（stopAndShoot）
+	StopY float32 `json:"stopY"`
+	// 悬停时间，毫秒（stopAndShoot）
+	HoldMs uint64 `json:"holdMs"`
+	// 悬停时的射击冷却时间，毫秒（stopAndShoot）
+	HoldCoolDownMs uint64 `json:"holdCoolDownMs"`
+	// 编队数量（formation）
+	Count int32 `json:"count"`
+	// 编队间距，像素（formation）
+	Spacing float32 `json:"spacing"`
+}
+
+// 敌人定义
+type enemyDef struct {
+	// 类型名字
+	Name string `json:"name"`
+	// 纹理路径
+	Texture string `json:"texture"`
+	// 纹理尺寸缩小倍数
+	ScaleDivisor float32 `json:"scaleDivisor"`
+	// 速度
+	Speed float32 `json:"speed"`
+	// 生命值
+	Health int32 `json:"health"`
+	// 射击冷却时间，毫秒
+	CoolDownMs uint64 `json:"coolDownMs"`
+	// 击毁得分
+	Score uint32 `json:"score"`
+	// 随机生成的权重
+	Weight float32 `json:"weight"`
+	// 移动方式
+	Movement movementDef `json:"movement"`
+	// 射击使用的弹幕名字
+	Pattern string `json:"pattern"`
+	// 碰撞盒，为空时使用整个纹理
+	Hitbox []hitboxDef `json:"hitbox"`
+}
+
+// 子弹行为定义
+type bulletDef struct {
+	// 加速度，像素每二次方秒，负数减速
+	Acceleration float32 `json:"acceleration"`
+	// 最大速度，0表示不限制
+	MaxSpeed float32 `json:"maxSpeed"`
+	// 最小速度
+	MinSpeed float32 `json:"minSpeed"`
+	// 角速度，度每秒，负数逆时针
+	AngularVelocityDeg float32 `json:"angularVelocityDeg"`
+	// 追踪玩家的最大转向速度，度每秒
+	HomingDegPerSec float32 `json:"homingDegPerSec"`
+	// 追踪持续时间，毫秒
+	HomingMs uint64 `json:"homingMs"`
+	// 延迟启动时间，毫秒
+	DelayMs uint64 `json:"delayMs"`
+	// 启动时是否重新瞄准玩家
+	AimOnActivate bool `json:"aimOnActivate"`
+}
+
+// 弹幕定义
+type patternDef struct {
+	// 名字
+	Name string `json:"name"`
+	// 发射器：ring一圈，spread扇形，spiral螺旋，aimedBurst瞄准连射，scatter随机散射
+	Emitter string `json:"emitter"`
+	// 子弹数量（aimedBurst为连射数量，ring、spiral为一圈的数量）
+	Count int32 `json:"count"`
+	// 扇形角度，度（spread、scatter）
+	SpreadDeg float32 `json:"spreadDeg"`
+	// 每次发射旋转的角度，度，负数逆时针（spiral）
+	RotateDeg float32 `json:"rotateDeg"`
+	// 连射间隔，毫秒（aimedBurst）
+	IntervalMs uint64 `json:"intervalMs"`
+	// 子弹初速度，配合延迟启动时可以为0
+	Speed float32 `json:"speed"`
+	// 子弹生成位置离发射中心的距离
+	Radius float32 `json:"radius"`
+	// 随机附加速度范围（scatter）
+	SpeedRange float32 `json:"speedRange"`
+	// 是否以玩家方向为中心，否则以正下方为中心（ring、spread、spiral、scatter）
+	Aimed bool `json:"aimed"`
+	// 子弹行为
+	Bullet bulletDef `json:"bullet"`
+}
+
+// Boss激光定义
+type laserDef struct {
+	// 预警时间，毫秒
+	WarnMs uint64 `json:"warnMs"`
+	// 持续时间，毫秒
+	DurationMs uint64 `json:"durationMs"`
+	// 宽度，像素
+	Width float32 `json:"width"`
+}
+
+// Boss攻击定义，弹幕和激光二选一
+type bossAttackDef struct {
+	// 攻击冷却时间，毫秒
+	CoolDownMs uint64 `json:"coolDownMs"`
+	// 弹幕名字
+	Pattern string `json:"pattern"`
+	// 激光
+	Laser *laserDef `json:"laser"`
+}
+
+// Boss阶段定义
+type bossPhaseDef struct {
+	// 生命值比例不高于该值时进入此阶段，第一个阶段必须为1
+	HealthBelow float32 `json:"healthBelow"`
+	// 该阶段同时进行的攻击
+	Attacks []bossAttackDef `json:"attacks"`
+}
+
+// Boss弱点定义，相对纹理尺寸[0,1]
+type weakPointDef struct {
+	X float32 `json:"x"`
+	Y float32 `json:"y"`
+	W float32 `json:"w"`
+	H float32 `json:"h"`
+}
+
+// Boss定义
+type bossDef struct {
+	// 类型名字
+	Name string `json:"name"`
+	// 纹理路径
+	Texture string `json:"texture"`
+	// 纹理尺寸缩小倍数
+	ScaleDivisor float32 `json:"scaleDivisor"`
+	// 入场速度
+	Speed float32 `json:"speed"`
+	// 生命值
+	Health int32 `json:"health"`
+	// 击毁得分
+	Score uint32 `json:"score"`
+	// 入场后停留的高度
+	EnterY float32 `json:"enterY"`
+	// 左右摆动幅度，像素
+	SwayAmplitude float32 `json:"swayAmplitude"`
+	// 左右摆动频率，每秒次数
+	SwayFrequency float32 `json:"swayFrequency"`
+	// 击中弱点以外部位的伤害倍数
+	BodyDamageScale float32 `json:"bodyDamageScale"`
+	// 碰撞盒，为空时使用整个纹理
+	Hitbox []hitboxDef `json:"hitbox"`
+	// 弱点列表
+	WeakPoints []weakPointDef `json:"weakPoints"`
+	// 阶段列表，按healthBelow从高到低排列
+	Phases []bossPhaseDef `json:"phases"`
+}
+
+// 爆炸定义
+type explosionDef struct {
+	// 序列帧纹理路径，每帧为正方形横向排列
+	Texture string `json:"texture"`
+	// 动画帧率
+	Fps uint32 `json:"fps"`
+}
+
+// 物品定义
+type itemDef struct {
+	// 物品类型：life生命，shield护盾，time时间减速，weapon武器，bomb炸弹
+	Type string `json:"type"`
+	// 武器名字（weapon）
+	Weapon string `json:"weapon"`
+	// 武器在weapons中的下标，校验时由名字解析（weapon）
+	weaponIndex int
+	// 纹理路径
+	Texture string `json:"texture"`
+	// 纹理尺寸缩小倍数
+	ScaleDivisor float32 `json:"scaleDivisor"`
+	// 速度
+	Speed float32 `json:"speed"`
+	// 弹跳次数
+	BounceCount int32 `json:"bounceCount"`
+	// 掉落权重
+	Weight float32 `json:"weight"`
+	// 效果持续时间，毫秒（shield、time）
+	DurationMs uint64 `json:"durationMs"`
+	// 效果结束前闪烁提示的时间，毫秒（shield、time）
+	WarnMs uint64 `json:"warnMs"`
+	// 效果纹理路径（shield）
+	EffectTexture string `json:"effectTexture"`
+	// 敌人和敌人子弹的时间缩放（time）
+	TimeScale float32 `json:"timeScale"`
+	// 碰撞盒，为空时使用整个纹理
+	Hitbox []hitboxDef `json:"hitbox"`
+}
+
+// 实体定义
+type entityDefs struct {
+	Player          playerDef     `json:"player"`
+	Bomb            bombDef       `json:"bomb"`
+	Weapons         []weaponDef   `json:"weapons"`
+	Enemies         []enemyDef    `json:"enemies"`
+	Bosses          []bossDef     `json:"bosses"`
+	Patterns        []patternDef  `json:"patterns"`
+	ProjectileEnemy projectileDef `json:"projectileEnemy"`
+	Explosion       explosionDef  `json:"explosion"`
+	DropChance      float32       `json:"dropChance"`
+	Items           []itemDef     `json:"items"`
+}
+
+// 实体定义使用的所有纹理路径
+func (defs *entityDefs) texturePaths() []string {
+	paths := []string{defs.Player.Texture, defs.Bomb.Icon, defs.ProjectileEnemy.Texture, defs.Explosion.Texture}
+	for i := range defs.Weapons {
+		for j := range defs.Weapons[i].Levels {
+			paths = append(paths, defs.Weapons[i].Levels[j].Texture)
+		}
+	}
+	for i := range defs.Enemies {
+		paths = append(paths, defs.Enemies[i].Texture)
+	}
+	for i := range defs.Bosses {
+		paths = append(paths, defs.Bosses[i].Texture)
+	}
+	for i := range defs.Items {
+		paths = append(paths, defs.Items[i].Texture)
+		if defs.Items[i].EffectTexture != "" {
+			paths = append(paths, defs.Items[i].EffectTexture)
+		}
+	}
+	return paths
+}
+
+// 载入并校验实体定义
+func loadEntityDefs(path string) (*entityDefs, error) {
+	data, err := GetInstance().assets.readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read entity defs, %v, %v", path, err)
+	}
+
+	defs := &entityDefs{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(defs); err != nil {
+		return nil, fmt.Errorf("failed to parse entity defs, %v, %v", path, jsonErrorWithLine(data, err))
+	}
+
+	v := &defValidator{path: path, patterns: make(map[string]bool)}
+	for i := range defs.Patterns {
+		def := &defs.Patterns[i]
+		name := fmt.Sprintf("patterns[%v]", i)
+		if def.Name == "" {
+			v.fail(name+".name", "is required")
+		} else if v.patterns[def.Name] {
+			v.fail(name+".name", "duplicated, %q", def.Name)
+		}
+		v.patterns[def.Name] = true
+		v.pattern(name, def)
+	}
+	v.texture("player.texture", defs.Player.Texture)
+	v.positive("player.scaleDivisor", defs.Player.ScaleDivisor)
+	v.positive("player.speed", defs.Player.Speed)
+	v.positive("player.focusSpeed", defs.Player.FocusSpeed)
+	v.hitbox("player.hitbox", defs.Player.Hitbox)
+	v.hitbox("player.core", defs.Player.Core)
+	if len(defs.Player.Core) == 0 {
+		v.fail("player.core", "must not be empty")
+	}
+	v.positive("player.health", float32(defs.Player.Health))
+	v.positive("player.hitInvincibleMs", float32(defs.Player.HitInvincibleMs))
+	v.positive("player.hitFlashMs", float32(defs.Player.HitFlashMs))
+	v.nonNegative("player.knockback", defs.Player.Knockback)
+	v.nonNegative("player.bombs", float32(defs.Player.Bombs))
+	if defs.Player.Bombs > defs.Player.MaxBombs {
+		v.fail("player.maxBombs", "must not be less than bombs, got %v", defs.Player.MaxBombs)
+	}
+	v.texture("bomb.icon", defs.Bomb.Icon)
+	v.positive("bomb.damage", float32(defs.Bomb.Damage))
+	v.nonNegative("bomb.bossDamage", defs.Bomb.BossDamage)
+	v.nonNegative("bomb.explosionCount", float32(defs.Bomb.ExplosionCount))
+	if len(defs.Weapons) == 0 {
+		v.fail("weapons", "must not be empty")
+	}
+	// 武器名字和下标
+	weapons := make(map[string]int)
+	for i := range defs.Weapons {
+		def := &defs.Weapons[i]
+		name := fmt.Sprintf("weapons[%v]", i)
+		if def.Name == "" {
+			v.fail(name+".name", "is required")
+		} else if _, ok := weapons[def.Name]; ok {
+			v.fail(name+".name", "duplicated, %q", def.Name)
+		} else {
+			weapons[def.Name] = i
+		}
+		v.weapon(name, def)
+	}
+	if index, ok := weapons[defs.Player.Weapon]; ok {
+		defs.Player.weaponIndex = index
+	} else {
+		v.fail("player.weapon", "unknown weapon, %q", defs.Player.Weapon)
+	}
+	if len(defs.Enemies) == 0 {
+		v.fail("enemies", "must not be empty")
+	}
+	names := make(map[string]bool)
+	for i := range defs.Enemies {
+		def := &defs.Enemies[i]
+		name := fmt.Sprintf("enemies[%v]", i)
+		if def.Name == "" {
+			v.fail(name+".name", "is required")
+		} else if names[def.Name] {
+			v.fail(name+".name", "duplicated, %q", def.Name)
+		}
+		names[def.Name] = true
+		v.enemy(name, def)
+	}
+	for i := range defs.Bosses {
+		def := &defs.Bosses[i]
+		name := fmt.Sprintf("bosses[%v]", i)
+		if def.Name == "" {
+			v.fail(name+".name", "is required")
+		} else if names[def.Name] {
+			v.fail(name+".name", "duplicated, %q", def.Name)
+		}
+		names[def.Name] = true
+		v.boss(name, def)
+	}
+	v.projectile("projectileEnemy", &defs.ProjectileEnemy)
+	v.texture("explosion.texture", defs.Explosion.Texture)
+	v.positive("explosion.fps", float32(defs.Explosion.Fps))
+	if defs.DropChance < 0 || defs.DropChance > 1 {
+		v.fail("dropChance", "must be in [0,1], got %v", defs.DropChance)
+	}
+	if len(defs.Items) == 0 {
+		v.fail("items", "must not be empty")
+	}
+	types := make(map[string]bool)
+	for i := range defs.Items {
+		def := &defs.Items[i]
+		name := fmt.Sprintf("items[%v]", i)
+		if def.Type == "weapon" {
+			if index, ok := weapons[def.Weapon]; ok {
+				def.weaponIndex = index
+			} else {
+				v.fail(name+".weapon", "unknown weapon, %q", def.Weapon)
+			}
+		} else if types[def.Type] {
+			v.fail(name+".type", "duplicated, %q", def.Type)
+		}
+		types[def.Type] = true
+		v.item(name, def)
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	return defs, nil
+}
+
+// 定义校验器，收集所有错误一起返回
+type defValidator struct {
+	path   string
+	errors []error
+	// 已定义的弹幕名字
+	patterns map[string]bool
+}
+
+func (v *defValidator) fail(field string, format string, args ...any) {
+	v.errors = append(v.errors, fmt.Errorf("%v: %v %v", v.path, field, fmt.Sprintf(format, args...)))
+}
+
+// 纹理路径必须存在
+func (v *defValidator) texture(field string, path string) {
+	if path == "" {
+		v.fail(field, "is required")
+		return
+	}
+	if !GetInstance().assets.exists(path) {
+		v.fail(field, "file not found, %q", path)
+	}
+}
+
+// 数值必须大于0
+func (v *defValidator) positive(field string, value float32) {
+	if value <= 0 {
+		v.fail(field, "must be > 0, got %v", value)
+	}
+}
+
+// 数值不能小于0
+func (v *defValidator) nonNegative(field string, value float32) {
+	if value < 0 {
+		v.fail(field, "must be >= 0, got %v", value)
+	}
+}
+
+// 校验敌人定义
+func (v *defValidator) enemy(name string, def *enemyDef) {
+	v.texture(name+".texture", def.Texture)
+	v.positive(name+".scaleDivisor", def.ScaleDivisor)
+	v.positive(name+".speed", def.Speed)
+	v.positive(name+".health", float32(def.Health))
+	v.positive(name+".coolDownMs", float32(def.CoolDownMs))
+	v.nonNegative(name+".weight", def.Weight)
+	v.patternName(name+".pattern", def.Pattern)
+	v.hitbox(name+".hitbox", def.Hitbox)
+
+	movement := &def.Movement
+	name += ".movement"
+	switch movement.Type {
+	case "straight":
+	case "sine":
+		v.positive(name+".amplitude", movement.Amplitude)
+		v.positive(name+".frequency", movement.Frequency)
+	case "dive":
+		v.positive(name+".triggerY", movement.TriggerY)
+		v.positive(name+".diveSpeed", movement.DiveSpeed)
+	case "strafe":
+		v.positive(name+".strafeSpeed", movement.StrafeSpeed)
+	case "stopAndShoot":
+		v.positive(name+".stopY", movement.StopY)
+		v.positive(name+".holdMs", float32(movement.HoldMs))
+		v.positive(name+".holdCoolDownMs", float32(movement.HoldCoolDownMs))
+	case "formation":
+		v.positive(name+".count", float32(movement.Count))
+		v.positive(name+".spacing", movement.Spacing)
+		v.nonNegative(name+".amplitude", movement.Amplitude)
+		v.nonNegative(name+".frequency", movement.Frequency)
+	default:
+		v.fail(name+".type", "unknown movement type, %q", movement.Type)
+	}
+}
+
+// 校验Boss定义
+func (v *defValidator) boss(name string, def *bossDef) {
+	v.texture(name+".texture", def.Texture)
+	v.positive(name+".scaleDivisor", def.ScaleDivisor)
+	v.positive(name+".speed", def.Speed)
+	v.positive(name+".health", float32(def.Health))
+	v.positive(name+".enterY", def.EnterY)
+	v.nonNegative(name+".swayAmplitude", def.SwayAmplitude)
+	v.nonNegative(name+".swayFrequency", def.SwayFrequency)
+	v.nonNegative(name+".bodyDamageScale", def.BodyDamageScale)
+	v.hitbox(name+".hitbox", def.Hitbox)
+	for i, point := range def.WeakPoints {
+		pointName := fmt.Sprintf("%v.weakPoints[%v]", name, i)
+		v.positive(pointName+".w", point.W)
+		v.positive(pointName+".h", point.H)
+		if point.X < 0 || point.Y < 0 || point.X+point.W > 1 || point.Y+point.H > 1 {
+			v.fail(pointName, "must be inside [0,1]")
+		}
+	}
+	if len(def.Phases) == 0 {
+		v.fail(name+".phases", "must not be empty")
+	}
+	for i := range def.Phases {
+		phase := &def.Phases[i]
+		phaseName := fmt.Sprintf("%v.phases[%v]", name, i)
+		if i == 0 && phase.HealthBelow != 1 {
+			v.fail(phaseName+".healthBelow", "must be 1 for the first phase, got %v", phase.HealthBelow)
+		}
+		if i > 0 && (phase.HealthBelow <= 0 || phase.HealthBelow >= def.Phases[i-1].HealthBelow) {
+			v.fail(phaseName+".healthBelow", "must be in (0,%v), got %v", def.Phases[i-1].HealthBelow, phase.HealthBelow)
+		}
+		if len(phase.Attacks) == 0 {
+			v.fail(phaseName+".attacks", "must not be empty")
+		}
+		for j := range phase.Attacks {
+			v.bossAttack(fmt.Sprintf("%v.attacks[%v]", phaseName, j), &phase.Attacks[j])
+		}
+	}
+}
+
+// 校验Boss攻击定义
+func (v *defValidator) bossAttack(name string, def *bossAttackDef) {
+	v.positive(name+".coolDownMs", float32(def.CoolDownMs))
+	if (def.Pattern == "") == (def.Laser == nil) {
+		v.fail(name, "must have exactly one of pattern and laser")
+		return
+	}
+	if def.Laser != nil {
+		v.positive(name+".laser.warnMs", float32(def.Laser.WarnMs))
+		v.positive(name+".laser.durationMs", float32(def.Laser.DurationMs))
+		v.positive(name+".laser.width", def.Laser.Width)
+		return
+	}
+	v.patternName(name+".pattern", def.Pattern)
+}
+
+// 弹幕名字必须已定义
+func (v *defValidator) patternName(field string, name string) {
+	if name == "" {
+		v.fail(field, "is required")
+	} else if !v.patterns[name] {
+		v.fail(field, "unknown pattern, %q", name)
+	}
+}
+
+// 校验弹幕定义
+func (v *defValidator) pattern(name string, def *patternDef) {
+	v.positive(name+".count", float32(def.Count))
+	v.nonNegative(name+".speed", def.Speed)
+	v.nonNegative(name+".radius", def.Radius)
+	if def.Speed == 0 && def.Bullet.Acceleration <= 0 {
+		v.fail(name+".bullet.acceleration", "must be > 0 when speed is 0, got %v", def.Bullet.Acceleration)
+	}
+	switch def.Emitter {
+	case "ring", "spiral":
+	case "spread":
+		v.nonNegative(name+".spreadDeg", def.SpreadDeg)
+	case "scatter":
+		v.positive(name+".spreadDeg", def.SpreadDeg)
+		v.nonNegative(name+".speedRange", def.SpeedRange)
+	case "aimedBurst":
+		if def.Count > 1 {
+			v.positive(name+".intervalMs", float32(def.IntervalMs))
+		}
+	default:
+		v.fail(name+".emitter", "unknown emitter, %q", def.Emitter)
+	}
+	bullet := &def.Bullet
+	v.nonNegative(name+".bullet.maxSpeed", bullet.MaxSpeed)
+	v.nonNegative(name+".bullet.minSpeed", bullet.MinSpeed)
+	v.nonNegative(name+".bullet.homingDegPerSec", bullet.HomingDegPerSec)
+	if bullet.MaxSpeed > 0 && bullet.MinSpeed > bullet.MaxSpeed {
+		v.fail(name+".bullet.minSpeed", "must not be greater than maxSpeed, got %v", bullet.MinSpeed)
+	}
+	if bullet.HomingDegPerSec > 0 {
+		v.positive(name+".bullet.homingMs", float32(bullet.HomingMs))
+	}
+}
+
+// 校验武器定义
+func (v *defValidator) weapon(name string, def *weaponDef) {
+	if len(def.Levels) == 0 {
+		v.fail(name+".levels", "must not be empty")
+	}
+	for i := range def.Levels {
+		level := &def.Levels[i]
+		levelName := fmt.Sprintf("%v.levels[%v]", name, i)
+		v.texture(levelName+".texture", level.Texture)
+		v.positive(levelName+".scaleDivisor", level.ScaleDivisor)
+		v.positive(levelName+".speed", level.Speed)
+		v.positive(levelName+".damage", float32(level.Damage))
+		v.positive(levelName+".coolDownMs", float32(level.CoolDownMs))
+		v.positive(levelName+".count", float32(level.Count))
+		v.nonNegative(levelName+".spreadDeg", level.SpreadDeg)
+		v.nonNegative(levelName+".spacing", level.Spacing)
+		v.nonNegative(levelName+".homingDegPerSec", level.HomingDegPerSec)
+		v.nonNegative(levelName+".pierce", float32(level.Pierce))
+		v.hitbox(levelName+".hitbox", level.Hitbox)
+	}
+}
+
+// 校验物品定义
+func (v *defValidator) item(name string, def *itemDef) {
+	v.texture(name+".texture", def.Texture)
+	v.positive(name+".scaleDivisor", def.ScaleDivisor)
+	v.positive(name+".speed", def.Speed)
+	v.nonNegative(name+".bounceCount", float32(def.BounceCount))
+	v.nonNegative(name+".weight", def.Weight)
+	v.hitbox(name+".hitbox", def.Hitbox)
+	switch def.Type {
+	case "life":
+	case "shield":
+		v.positive(name+".durationMs", float32(def.DurationMs))
+		v.texture(name+".effectTexture", def.EffectTexture)
+	case "time":
+		v.positive(name+".durationMs", float32(def.DurationMs))
+		if def.TimeScale <= 0 || def.TimeScale >= 1 {
+			v.fail(name+".timeScale", "must be in (0,1), got %v", def.TimeScale)
+		}
+	case "weapon", "bomb":
+	default:
+		v.fail(name+".type", "unknown item type, %q", def.Type)
+	}
+	if def.WarnMs > def.DurationMs {
+		v.fail(name+".warnMs", "must not be greater than durationMs, got %v", def.WarnMs)
+	}
+}
+
+// 校验子弹定义
+func (v *defValidator) projectile(name string, def *projectileDef) {
+	v.texture(name+".texture", def.Texture)
+	v.positive(name+".scaleDivisor", def.ScaleDivisor)
+	v.positive(name+".speed", def.Speed)
+	v.positive(name+".damage", float32(def.Damage))
+	v.hitbox(name+".hitbox", def.Hitbox)
+}
+
+// 校验碰撞盒定义
+func (v *defValidator) hitbox(name string, defs []hitboxDef) {
+	for i, def := range defs {
+		shapeName := fmt.Sprintf("%v[%v]", name, i)
+		switch def.Type {
+		case "rect":
+			v.positive(shapeName+".w", def.W)
+			v.positive(shapeName+".h", def.H)
+		case "circle":
+			v.positive(shapeName+".r", def.R)
+		default:
+			v.fail(shapeName+".type", "unknown shape, %q", def.Type)
+		}
+	}
+}
+
+func (v *defValidator) err() error {
+	return errors.Join(v.errors...)
+}
+
+// 为JSON错误加上行号
+func jsonErrorWithLine(data []byte, err error) error {
+	var offset int64 = -1
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) {
+		offset = syntaxErr.Offset
+	} else if errors.As(err, &typeErr) {
+		offset = typeErr.Offset
+	}
+	if offset < 0 || offset > int64(len(data)) {
+		return err
+	}
+	line := bytes.Count(data[:offset], []byte("\n")) + 1
+	return fmt.Errorf("line %v: %w", line, err)
+}
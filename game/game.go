@@ -4,10 +4,11 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 	"sync"
+	"time"
 
-	"github.com/SunshineZzzz/purego-sdl3/img"
 	"github.com/SunshineZzzz/purego-sdl3/sdl"
 	"github.com/SunshineZzzz/purego-sdl3/ttf"
 )
@@ -20,32 +21,61 @@ var (
 func GetInstance() *Game {
 	once.Do(func() {
 		instance = &Game{
-			fps:          60,
-			frameTime:    1000000000 / 60,
+			fps:          defaultFrameRate,
+			frameTime:    1000000000 / defaultFrameRate,
+			fixedStep:    true,
+			tickRate:     120,
+			tickTime:     1000000000 / 120,
+			accumulator:  0,
+			alpha:        1.0,
 			isRunning:    false,
+			headless:     false,
 			windowWidth:  600,
 			windowHeight: 800,
 			sdlWindow:    nil,
 			sdlRenderer:  nil,
+			assets:       newAssetManager(),
 			titleFont:    nil,
 			textFont:     nil,
 			deltaTime:    float32(0.0),
 			isFullscreen: false,
-			currentScene: nil,
+			showHitboxes: false,
+			bindings:     newInputBindings(),
+			gamepads:     make(map[sdl.JoystickID]*sdl.Gamepad),
 			finalScore:   0,
+			fixedSeed:    false,
+			seed:         0,
+			finalSeed:    0,
+			finalCleared: false,
+			replayPath:   "",
 			leaderBoard:  make(map[uint32][]string),
 		}
 	})
 	return instance
 }
 
+// 默认的渲染帧率上限，高于逻辑更新频率，高刷新率显示器上由渲染插值补足逻辑帧之间的画面
+const defaultFrameRate = 240
+
 type Game struct {
-	// FPS
+	// 渲染帧率上限，0表示不限制，和逻辑更新频率无关
 	fps uint32
-	// 每帧时间间隔，单位纳秒
+	// 每帧最短时间间隔，单位纳秒，0表示不限制
 	frameTime uint64
+	// 是否使用固定步长更新逻辑
+	fixedStep bool
+	// 逻辑更新频率
+	tickRate uint32
+	// 每次逻辑更新的时间间隔，单位纳秒
+	tickTime uint64
+	// 尚未消耗的累计时间，单位纳秒
+	accumulator uint64
+	// 渲染插值系数，当前时刻位于上一逻辑帧和当前逻辑帧之间的比例
+	alpha float32
 	// 是否运行中
 	isRunning bool
+	// 是否无头模式，不创建窗口、渲染器和音频设备
+	headless bool
 	// 窗口宽高
 	windowWidth  int32
 	windowHeight int32
@@ -56,25 +86,66 @@ type Game struct {
 	// 背景
 	nearStars background
 	farStars  background
+	// 资源管理器
+	assets *assetManager
 	// 标题字体
 	titleFont *ttf.Font
 	// 文字字体
 	textFont *ttf.Font
-	// 两帧时间差，秒
+	// 逻辑更新时间差，秒
 	deltaTime float32
 	// 是否全屏
 	isFullscreen bool
-	// 当前场景
-	currentScene iscene
+	// 是否显示碰撞盒，调试用
+	showHitboxes bool
+	// 输入动作绑定
+	bindings *inputBindings
+	// 已连接的手柄
+	gamepads map[sdl.JoystickID]*sdl.Gamepad
+	// 场景栈，最上面的场景为当前场景
+	scenes []iscene
+	// 正在进行的场景切换
+	transition transitionState
 	// 最终得分
 	finalScore uint32
+	// 是否指定了随机种子
+	fixedSeed bool
+	// 指定的随机种子
+	seed int64
+	// 最近一局的随机种子
+	finalSeed int64
+	// 最近一局是否通过所有关卡
+	finalCleared bool
+	// 启动时播放的回放文件
+	replayPath string
 	// 排行榜
 	leaderBoard map[uint32][]string
 }
 
+const (
+	// 近背景
+	nearStarsPath = "assets/image/Stars-A.png"
+	// 远背景
+	farStarsPath = "assets/image/Stars-B.png"
+	// 排行榜数据
+	saveDataPath = "assets/save.dat"
+	// 标题和文字字体
+	uiFontPath = "assets/font/VonwaonBitmap-16px.ttf"
+	// 标题字号
+	titleFontSize = 64.0
+	// 文字字号
+	textFontSize = 32.0
+)
+
+// 所有场景共用的资源，在游戏的整个生命周期中保持载入
+var gameAssets = assetManifest{
+	textures: []string{nearStarsPath, farStarsPath},
+	fonts:    []fontKey{{path: uiFontPath, size: titleFontSize}, {path: uiFontPath, size: textFontSize}},
+}
+
 func (g *Game) Init() error {
 	// 初始化 SDL
-	if !sdl.Init(sdl.InitVideo | sdl.InitAudio | sdl.InitEvents) {
+	if !sdl.Init(sdl.InitVideo | sdl.InitAudio | sdl.InitEvents | sdl.InitGamepad) {
 		return fmt.Errorf("sdl init error,%s", sdl.GetError())
 	}
 
@@ -100,61 +171,157 @@ func (g *Game) Init() error {
 		return fmt.Errorf("ttf init error,%s", sdl.GetError())
 	}
 
+	// 预载所有场景共用的资源
+	if err := g.assets.preload(&gameAssets); err != nil {
+		return err
+	}
+
 	// 初始化近背景卷轴
 	g.nearStars.speed = 30.0
-	g.nearStars.texture = img.LoadTexture(g.sdlRenderer, "assets/image/Stars-A.png")
-	if g.nearStars.texture == nil {
-		return fmt.Errorf("load near stars texture error,%s", sdl.GetError())
-	}
-	sdl.GetTextureSize(g.nearStars.texture, &g.nearStars.width, &g.nearStars.height)
-	g.nearStars.width /= 2
-	g.nearStars.height /= 2
+	near := g.assets.texture(nearStarsPath)
+	g.nearStars.texture = near.texture
+	g.nearStars.width = near.width / 2
+	g.nearStars.height = near.height / 2
 	// 初始化远背景卷轴
 	g.farStars.speed = 30.0
-	g.farStars.texture = img.LoadTexture(g.sdlRenderer, "assets/image/Stars-B.png")
-	if g.farStars.texture == nil {
-		return fmt.Errorf("load far stars texture error,%s", sdl.GetError())
-	}
-	sdl.GetTextureSize(g.farStars.texture, &g.farStars.width, &g.farStars.height)
-	g.farStars.width /= 2
-	g.farStars.height /= 2
+	far := g.assets.texture(farStarsPath)
+	g.farStars.texture = far.texture
+	g.farStars.width = far.width / 2
+	g.farStars.height = far.height / 2
 
-	// 载入字体
-	g.titleFont = ttf.OpenFont("assets/font/VonwaonBitmap-16px.ttf", 64.0)
-	if g.titleFont == nil {
-		return fmt.Errorf("load title font error,%s", sdl.GetError())
-	}
-	g.textFont = ttf.OpenFont("assets/font/VonwaonBitmap-16px.ttf", 32.0)
-	if g.textFont == nil {
-		return fmt.Errorf("load text font error,%s", sdl.GetError())
-	}
+	// 字体
+	g.titleFont = g.assets.font(uiFontPath, titleFontSize)
+	g.textFont = g.assets.font(uiFontPath, textFontSize)
 
 	// 载入排行榜
 	g.loadData()
 
-	// 创建标题场景
-	g.currentScene = &sceneTitle{}
-	g.currentScene.init()
+	// 载入按键绑定，配置文件不存在时写入默认绑定方便修改
+	if err := g.bindings.load(g.assets.dataPath(bindingsPath)); err != nil {
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("load key bindings error,%v", err)
+		}
+		if err := g.bindings.save(g.assets.dataPath(bindingsPath)); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	// 创建标题场景，指定了回放文件时直接进入回放
+	if g.replayPath != "" {
+		g.pushScene(g.loadingScene(&sceneReplay{path: g.replayPath}, fadeTransition))
+	} else {
+		g.pushScene(g.loadingScene(&sceneTitle{}, fadeTransition))
+	}
 
 	g.isRunning = true
 	return nil
 }
 
+// 设置是否使用固定步长更新逻辑，tickRate为每秒逻辑更新次数
+func (g *Game) SetFixedStep(enable bool, tickRate uint32) {
+	g.fixedStep = enable
+	if tickRate > 0 {
+		g.tickRate = tickRate
+		g.tickTime = 1000000000 / uint64(tickRate)
+	}
+	g.accumulator = 0
+	g.alpha = 1.0
+}
+
+// 设置渲染帧率上限，0表示不限制，固定步长下不影响逻辑更新频率
+func (g *Game) SetFrameRate(fps uint32) {
+	g.fps = fps
+	g.frameTime = 0
+	if fps > 0 {
+		g.frameTime = 1000000000 / uint64(fps)
+	}
+}
+
+// 指定随机种子，之后每一局都使用该种子，相同种子和输入可以复现同一局游戏
+func (g *Game) SetSeed(seed int64) {
+	g.fixedSeed = true
+	g.seed = seed
+}
+
+// 获取新一局的随机种子，未指定种子时随机生成
+func (g *Game) nextSeed() int64 {
+	if g.fixedSeed {
+		return g.seed
+	}
+	return time.Now().UnixNano()
+}
+
+// 指定启动时播放的回放文件
+func (g *Game) SetReplay(path string) {
+	g.replayPath = path
+}
+
+// 重新绑定动作的按键并保存到配置文件
+func (g *Game) rebind(a action, keys []sdl.Scancode) {
+	g.bindings.bind(a, keys)
+	if err := g.bindings.save(g.assets.dataPath(bindingsPath)); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// 切换全屏
+func (g *Game) toggleFullscreen() {
+	g.isFullscreen = !g.isFullscreen
+	sdl.SetWindowFullscreen(g.sdlWindow, g.isFullscreen)
+}
+
 func (g *Game) Run() {
+	lastTime := sdl.GetTicksNS()
 	for g.isRunning {
 		frameStart := sdl.GetTicksNS()
+		elapsed := frameStart - lastTime
+		lastTime = frameStart
+
 		g.handleEvent()
-		g.update()
+		if g.fixedStep {
+			g.fixedUpdate(elapsed)
+		} else {
+			g.update()
+		}
 		g.render()
+
 		frameEnd := sdl.GetTicksNS()
 		diff := frameEnd - frameStart
 		if diff < g.frameTime {
 			sdl.DelayNS(g.frameTime - diff)
-			g.deltaTime = float32(g.frameTime) / 1e9
+			if !g.fixedStep {
+				g.deltaTime = float32(g.frameTime) / 1e9
+			}
 			continue
 		}
-		g.deltaTime = float32(diff) / 1e9
+		if !g.fixedStep {
+			g.deltaTime = float32(diff) / 1e9
+		}
+	}
+}
+
+// 固定步长更新，按累计时间执行若干次逻辑更新，剩余时间用于渲染插值
+func (g *Game) fixedUpdate(elapsed uint64) {
+	// 避免卡顿后一次追赶过多逻辑帧
+	maxElapsed := g.tickTime * 8
+	if elapsed > maxElapsed {
+		elapsed = maxElapsed
+	}
+	g.deltaTime = float32(g.tickTime) / 1e9
+	g.accumulator += elapsed
+	for g.accumulator >= g.tickTime {
+		g.update()
+		g.accumulator -= g.tickTime
 	}
+	g.alpha = float32(g.accumulator) / float32(g.tickTime)
+}
+
+// 按渲染插值系数计算上一逻辑帧和当前逻辑帧之间的位置
+func (g *Game) interpolate(last sdl.FPoint, current sdl.FPoint) sdl.FPoint {
+	if !g.fixedStep {
+		return current
+	}
+	return lerpPoint(last, current, g.alpha)
 }
 
 func (g *Game) handleEvent() {
@@ -164,25 +331,42 @@ func (g *Game) handleEvent() {
 			g.isRunning = false
 			return
 		}
-		if event.Type() == sdl.EventKeyDown {
-			if event.Key().Scancode == sdl.ScancodeF4 {
-				g.isFullscreen = !g.isFullscreen
-				sdl.SetWindowFullscreen(g.sdlWindow, g.isFullscreen)
-			}
+		g.handleGamepadEvent(event)
+		if g.bindings.isPressed(event, actionToggleFullscreen) {
+			g.toggleFullscreen()
+		}
+		if g.bindings.isPressed(event, actionToggleHitboxes) {
+			g.showHitboxes = !g.showHitboxes
 		}
 		if event.Type() == sdl.EventWindowResized {
 			g.windowWidth = event.Window().Data1
 			g.windowHeight = event.Window().Data2
 			sdl.SetRenderLogicalPresentation(g.sdlRenderer, g.windowWidth, g.windowHeight, sdl.LogicalPresentationLetterbox)
 		}
-		g.currentScene.handleEvent(event)
+		// 切换场景期间不接收事件
+		if scene := g.topScene(); scene != nil && !g.isTransitioning() {
+			scene.handleEvent(event)
+		}
 	}
 }
 
 func (g *Game) update() {
+	// 处理后台载入完成的资源
+	g.assets.update()
 	g.backgroundUpdate(g.deltaTime)
+	// 切换场景期间场景暂停
+	if g.isTransitioning() {
+		g.updateTransition(g.deltaTime)
+		// 载入场景在切换期间继续载入，资源已经在缓存中时不用等切换结束
+		if loading, ok := g.topScene().(*sceneLoading); ok {
+			loading.update(g.deltaTime)
+		}
+		return
+	}
 	// 更新当前场景
-	g.currentScene.update(g.deltaTime)
+	if scene := g.topScene(); scene != nil {
+		scene.update(g.deltaTime)
+	}
 }
 
 func (g *Game) render() {
@@ -191,58 +375,57 @@ func (g *Game) render() {
 
 	// 渲染星空背景
 	g.renderBackground()
-	// 渲染当前场景
-	g.currentScene.render()
+	// 渲染场景栈，上面的场景覆盖在下面的场景上
+	g.renderScenes()
+	// 渲染场景切换效果
+	g.renderTransition()
 
 	// 显示更新
 	sdl.RenderPresent(g.sdlRenderer)
 }
 
 func (g *Game) Clean() {
-	if g.currentScene != nil {
-		g.currentScene.clean()
-		g.currentScene = nil
-	}
-
-	if g.nearStars.texture != nil {
-		sdl.DestroyTexture(g.nearStars.texture)
-		g.nearStars.texture = nil
-	}
-
-	if g.farStars.texture != nil {
-		sdl.DestroyTexture(g.farStars.texture)
-		g.farStars.texture = nil
-	}
-
-	if g.titleFont != nil {
-		ttf.CloseFont(g.titleFont)
-		g.titleFont = nil
-	}
-
-	if g.textFont != nil {
-		ttf.CloseFont(g.textFont)
-		g.textFont = nil
-	}
-
+	g.endTransition()
+	g.clearScenes()
+
+	// 等待后台载入结束后释放所有缓存的资源
+	g.assets.wait()
+	g.assets.release(&gameAssets)
+	g.assets.collect(true)
+	g.assets.closeSource()
+	g.nearStars.texture = nil
+	g.farStars.texture = nil
+	g.titleFont = nil
+	g.textFont = nil
+
+	g.closeGamepads()
 	ttf.Quit()
 	sdl.DestroyRenderer(g.sdlRenderer)
 	sdl.DestroyWindow(g.sdlWindow)
 	sdl.Quit()
 
 	// 保存排行榜数据
-	g.saveData()
+	if err := g.saveData(); err != nil {
+		fmt.Println(err)
+	}
 }
 
 func (g *Game) renderBackground() {
+	farOffset := g.farStars.offset
+	nearOffset := g.nearStars.offset
+	if g.fixedStep {
+		farOffset = lerp(g.farStars.lastOffset, g.farStars.offset, g.alpha)
+		nearOffset = lerp(g.nearStars.lastOffset, g.nearStars.offset, g.alpha)
+	}
 	// 渲染远处的星星
-	for posY := g.farStars.offset; posY < float32(g.windowHeight); posY += g.farStars.height {
+	for posY := farOffset; posY < float32(g.windowHeight); posY += g.farStars.height {
 		for posX := float32(0.0); posX < float32(g.windowWidth); posX += g.farStars.width {
 			ds := sdl.FRect{X: posX, Y: posY, W: g.farStars.width, H: g.farStars.height}
 			sdl.RenderTexture(g.sdlRenderer, g.farStars.texture, nil, &ds)
 		}
 	}
 	// 渲染近处的星星
-	for posY := g.nearStars.offset; posY < float32(g.windowHeight); posY += g.nearStars.height {
+	for posY := nearOffset; posY < float32(g.windowHeight); posY += g.nearStars.height {
 		for posX := float32(0.0); posX < float32(g.windowWidth); posX += g.nearStars.width {
 			ds := sdl.FRect{X: posX, Y: posY, W: g.nearStars.width, H: g.nearStars.height}
 			sdl.RenderTexture(g.sdlRenderer, g.nearStars.texture, nil, &ds)
@@ -251,14 +434,19 @@ func (g *Game) renderBackground() {
 }
 
 func (g *Game) backgroundUpdate(deltaTime float32) {
+	g.nearStars.lastOffset = g.nearStars.offset
 	g.nearStars.offset += g.nearStars.speed * deltaTime
 	if g.nearStars.offset >= 0 {
 		g.nearStars.offset -= g.nearStars.height
+		// 同步回绕，避免插值跨越整个纹理高度
+		g.nearStars.lastOffset -= g.nearStars.height
 	}
 
+	g.farStars.lastOffset = g.farStars.offset
 	g.farStars.offset += g.farStars.speed * deltaTime
 	if g.farStars.offset >= 0 {
 		g.farStars.offset -= g.farStars.height
+		g.farStars.lastOffset -= g.farStars.height
 	}
 }
 
@@ -314,13 +502,12 @@ func (g *Game) renderTextPos(text string, posX float32, posY float32, isLeft boo
 	sdl.DestroyTexture(texture)
 }
 
-func (g *Game) changeScene(scene iscene) {
-	if g.currentScene != nil {
-		g.currentScene.clean()
-		g.currentScene = nil
-	}
-	g.currentScene = scene
-	g.currentScene.init()
+// 用指定颜色填充矩形，绘制后恢复清屏使用的黑色
+func (g *Game) renderFillRect(rect sdl.FRect, color sdl.Color) {
+	sdl.SetRenderDrawBlendMode(g.sdlRenderer, sdl.BlendModeBlend)
+	sdl.SetRenderDrawColor(g.sdlRenderer, color.R, color.G, color.B, color.A)
+	sdl.RenderFillRect(g.sdlRenderer, &rect)
+	sdl.SetRenderDrawColor(g.sdlRenderer, 0, 0, 0, 255)
 }
 
 func (g *Game) insertLeaderBoard(score uint32, name string) {
@@ -360,11 +547,15 @@ func (g *Game) insertLeaderBoard(score uint32, name string) {
 	}
 }
 
-func (g *Game) saveData() {
+func (g *Game) saveData() error {
 	// 保存得分榜的数据
-	file, err := os.Create("assets/save.dat")
+	path := g.assets.dataPath(saveDataPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create save data dir, %v, %v", path, err)
+	}
+	file, err := os.Create(path)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to create save data, %v, %v", path, err)
 	}
 	defer file.Close()
 	for score, names := range g.leaderBoard {
@@ -372,16 +563,18 @@ func (g *Game) saveData() {
 			fmt.Fprintf(file, "%v %v\n", score, name)
 		}
 	}
+	return nil
 }
 
 func (g *Game) loadData() {
-	// 加载得分榜的数据
-	file, err := os.OpenFile("assets/save.dat", os.O_RDONLY, 0644)
+	// 加载得分榜的数据，读取失败时使用空的排行榜
+	path := g.assets.dataPath(saveDataPath)
+	file, err := os.OpenFile(path, os.O_RDONLY, 0644)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return
+		if !os.IsNotExist(err) {
+			fmt.Printf("failed to open save data, %v, %v\n", path, err)
 		}
-		panic(err)
+		return
 	}
 	defer file.Close()
 	g.leaderBoard = make(map[uint32][]string)
@@ -0,0 +1,38 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/SunshineZzzz/purego-sdl3/sdl"
+)
+
+// 处理手柄热插拔事件
+func (g *Game) handleGamepadEvent(event sdl.Event) {
+	switch event.Type() {
+	case sdl.EventGamepadAdded:
+		id := event.GDevice().Which
+		if _, ok := g.gamepads[id]; ok {
+			return
+		}
+		gamepad := sdl.OpenGamepad(id)
+		if gamepad == nil {
+			fmt.Printf("failed to open gamepad %v: %s\n", id, sdl.GetError())
+			return
+		}
+		g.gamepads[id] = gamepad
+	case sdl.EventGamepadRemoved:
+		id := event.GDevice().Which
+		if gamepad, ok := g.gamepads[id]; ok {
+			sdl.CloseGamepad(gamepad)
+			delete(g.gamepads, id)
+		}
+	}
+}
+
+// 关闭所有手柄
+func (g *Game) closeGamepads() {
+	for id, gamepad := range g.gamepads {
+		sdl.CloseGamepad(gamepad)
+		delete(g.gamepads, id)
+	}
+}
@@ -0,0 +1,151 @@
+package game
+
+import (
+	"math"
+	"testing"
+	"unsafe"
+
+	"github.com/SunshineZzzz/purego-sdl3/sdl"
+)
+
+// 虚拟手柄，用于没有实体手柄的环境下检查手柄输入
+type virtualGamepad struct {
+	id       sdl.JoystickID
+	joystick *sdl.Joystick
+}
+
+// 挂载虚拟手柄
+func attachVirtualGamepad(t *testing.T) *virtualGamepad {
+	t.Helper()
+	desc := sdl.VirtualJoystickDesc{
+		Type:     uint16(sdl.JoystickTypeGamepad),
+		Naxes:    6,
+		Nbuttons: 15,
+	}
+	desc.Version = uint32(unsafe.Sizeof(desc))
+	id := sdl.AttachVirtualJoystick(&desc)
+	if id == 0 {
+		t.Fatalf("attach virtual joystick error,%s", sdl.GetError())
+	}
+	joystick := sdl.OpenJoystick(id)
+	if joystick == nil {
+		sdl.DetachVirtualJoystick(id)
+		t.Fatalf("open virtual joystick error,%s", sdl.GetError())
+	}
+	pad := &virtualGamepad{id: id, joystick: joystick}
+	t.Cleanup(pad.detach)
+	return pad
+}
+
+// 设置虚拟手柄摇杆轴，value范围[-1,1]
+func (v *virtualGamepad) setAxis(axis sdl.GamepadAxis, value float32) {
+	sdl.SetJoystickVirtualAxis(v.joystick, int32(axis), int16(value*32767))
+}
+
+// 设置虚拟手柄按键
+func (v *virtualGamepad) setButton(button sdl.GamepadButton, down bool) {
+	sdl.SetJoystickVirtualButton(v.joystick, int32(button), down)
+}
+
+// 卸载虚拟手柄，可以重复调用
+func (v *virtualGamepad) detach() {
+	if v.joystick == nil {
+		return
+	}
+	sdl.CloseJoystick(v.joystick)
+	sdl.DetachVirtualJoystick(v.id)
+	v.joystick = nil
+}
+
+// 初始化SDL的事件和手柄子系统，SDL动态库无法载入时跳过测试
+func initGamepadSDL(t *testing.T) {
+	t.Helper()
+	loaded := func() (ok bool) {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Logf("load sdl error,%v", r)
+				ok = false
+			}
+		}()
+		if !sdl.Init(sdl.InitEvents | sdl.InitGamepad) {
+			t.Logf("sdl init error,%s", sdl.GetError())
+			return false
+		}
+		return true
+	}()
+	if !loaded {
+		t.Skip("sdl not available")
+	}
+	t.Cleanup(sdl.Quit)
+}
+
+// 处理手柄事件并刷新手柄状态，返回期间触发的动作
+func pumpGamepadEvents(g *Game) map[action]bool {
+	sdl.UpdateGamepads()
+	pressed := make(map[action]bool)
+	var event sdl.Event
+	for sdl.PollEvent(&event) {
+		g.handleGamepadEvent(event)
+		for a := range actionCount {
+			if g.bindings.isPressed(event, a) {
+				pressed[a] = true
+			}
+		}
+	}
+	return pressed
+}
+
+// 用虚拟手柄检查手柄输入链路：热插拔事件、摇杆死区和按键绑定，不需要窗口和实体手柄
+func TestVirtualGamepad(t *testing.T) {
+	initGamepadSDL(t)
+	g := GetInstance()
+	t.Cleanup(g.closeGamepads)
+
+	pad := attachVirtualGamepad(t)
+	// 处理挂载产生的热插拔事件
+	pumpGamepadEvents(g)
+	if len(g.gamepads) == 0 {
+		t.Fatal("virtual gamepad not opened")
+	}
+
+	input := deviceInput{}
+	// 死区内的摇杆输入应当被忽略
+	pad.setAxis(sdl.GamepadAxisLeftX, g.bindings.deadzone/2)
+	pumpGamepadEvents(g)
+	if state := input.poll(); state.moveX != 0 || state.moveY != 0 {
+		t.Errorf("stick inside deadzone moved player, %v, %v", state.moveX, state.moveY)
+	}
+
+	// 摇杆推到底为全速
+	pad.setAxis(sdl.GamepadAxisLeftX, 1)
+	pad.setAxis(sdl.GamepadAxisLeftY, 0)
+	pad.setButton(sdl.GamepadButtonSouth, true)
+	pumpGamepadEvents(g)
+	state := input.poll()
+	if math.Abs(float64(state.moveX-1)) > 0.01 || state.moveY != 0 {
+		t.Errorf("stick full right read as %v, %v", state.moveX, state.moveY)
+	}
+	if !state.fire {
+		t.Error("fire button not detected")
+	}
+	pad.setButton(sdl.GamepadButtonSouth, false)
+	pumpGamepadEvents(g)
+	if input.poll().fire {
+		t.Error("fire still down after release")
+	}
+
+	// 开始键暂停
+	pad.setButton(sdl.GamepadButtonStart, true)
+	if pressed := pumpGamepadEvents(g); !pressed[actionPause] {
+		t.Error("pause button not detected")
+	}
+	pad.setButton(sdl.GamepadButtonStart, false)
+	pumpGamepadEvents(g)
+
+	// 拔出后不再有输入
+	pad.detach()
+	pumpGamepadEvents(g)
+	if len(g.gamepads) != 0 {
+		t.Error("virtual gamepad not closed after removal")
+	}
+}
@@ -0,0 +1,163 @@
+package game
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/png"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// 无头模式运行结果
+type HeadlessResult struct {
+	// 随机种子
+	Seed int64
+	// 最终得分
+	Score uint32
+	// 运行的逻辑帧数
+	Ticks uint64
+	// 是否死亡
+	IsDead bool
+	// 死亡时的逻辑帧
+	DeathTick uint64
+	// 是否通过所有关卡
+	Cleared bool
+}
+
+// 脚本输入的一段，持续ticks个逻辑帧
+type scriptStep struct {
+	ticks uint64
+	state inputState
+}
+
+// 脚本输入源，按逻辑帧回放预先编写的输入，脚本结束后不再有输入
+type scriptedInput struct {
+	steps []scriptStep
+	// 当前段
+	index int
+	// 当前段已经执行的逻辑帧数
+	elapsed uint64
+}
+
+var _ inputSource = (*scriptedInput)(nil)
+
+// 载入输入脚本
+// 每行格式为"逻辑帧数 按键"，按键由U(上)、D(下)、L(左)、R(右)、F(开火)、B(炸弹)、S(低速)组合，-表示无输入，#开头为注释
+// 例如"120 RF"表示按住右和开火120个逻辑帧
+func loadInputScript(path string) (*scriptedInput, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open input script, %v, %v", path, err)
+	}
+	defer file.Close()
+
+	script := &scriptedInput{}
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid input script line, %v:%v, %q", path, lineNum, line)
+		}
+		ticks, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tick count, %v:%v, %v", path, lineNum, err)
+		}
+		var state inputState
+		if fields[1] != "-" {
+			var up, down, left, right bool
+			for _, key := range strings.ToUpper(fields[1]) {
+				switch key {
+				case 'U':
+					up = true
+				case 'D':
+					down = true
+				case 'L':
+					left = true
+				case 'R':
+					right = true
+				case 'F':
+					state.fire = true
+				case 'B':
+					state.bomb = true
+				case 'S':
+					state.focus = true
+				default:
+					return nil, fmt.Errorf("invalid key %q, %v:%v", key, path, lineNum)
+				}
+			}
+			state.moveX = axisFromButtons(left, right)
+			state.moveY = axisFromButtons(up, down)
+		}
+		script.steps = append(script.steps, scriptStep{ticks: ticks, state: state})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read input script, %v, %v", path, err)
+	}
+	return script, nil
+}
+
+func (s *scriptedInput) poll() inputState {
+	for s.index < len(s.steps) && s.elapsed >= s.steps[s.index].ticks {
+		s.index++
+		s.elapsed = 0
+	}
+	if s.index >= len(s.steps) {
+		return inputState{}
+	}
+	s.elapsed++
+	return s.steps[s.index].state
+}
+
+// 由图片文件数据读取图片尺寸，无头模式下代替纹理尺寸
+func imageSize(path string, data []byte) (float32, float32, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to decode image config, %v, %v", path, err)
+	}
+	return float32(config.Width), float32(config.Height), nil
+}
+
+// 无头模式运行sceneMain，不创建窗口、渲染器和音频设备，由脚本驱动输入，脚本为空时没有输入
+// 玩家死亡、通过所有关卡或者达到maxTicks个逻辑帧后结束
+func (g *Game) RunHeadless(scriptPath string, maxTicks uint64) (result HeadlessResult, err error) {
+	script := &scriptedInput{}
+	if scriptPath != "" {
+		script, err = loadInputScript(scriptPath)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return g.runHeadless(&sceneMain{input: script}, maxTicks)
+}
+
+// 无头模式运行场景直到玩家死亡、通过所有关卡或者达到maxTicks个逻辑帧
+func (g *Game) runHeadless(scene *sceneMain, maxTicks uint64) (result HeadlessResult, err error) {
+	g.headless = true
+	g.deltaTime = float32(g.tickTime) / 1e9
+
+	if err := scene.init(); err != nil {
+		return result, fmt.Errorf("headless run error,%v", err)
+	}
+	defer scene.clean()
+	for scene.tick < maxTicks && !scene.isDead && !scene.isCleared {
+		scene.update(g.deltaTime)
+	}
+
+	result.Seed = scene.seed
+	result.Score = scene.score
+	result.Ticks = scene.tick
+	result.IsDead = scene.isDead
+	result.DeathTick = scene.deathTick
+	result.Cleared = scene.isCleared
+	return result, nil
+}
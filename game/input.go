@@ -0,0 +1,387 @@
+package game
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/SunshineZzzz/purego-sdl3/sdl"
+)
+
+// 按键绑定配置文件
+const bindingsPath = "assets/keybind.cfg"
+
+// 输入动作
+type action int32
+
+const (
+	actionMoveUp action = iota
+	actionMoveDown
+	actionMoveLeft
+	actionMoveRight
+	actionFire
+	actionBomb
+	actionFocus
+	actionConfirm
+	actionPause
+	actionToggleFullscreen
+	actionToggleHitboxes
+	actionTextSubmit
+	actionTextDelete
+	actionCount
+)
+
+// 动作名字，用于配置文件
+var actionNames = [actionCount]string{
+	actionMoveUp:           "MoveUp",
+	actionMoveDown:         "MoveDown",
+	actionMoveLeft:         "MoveLeft",
+	actionMoveRight:        "MoveRight",
+	actionFire:             "Fire",
+	actionBomb:             "Bomb",
+	actionFocus:            "Focus",
+	actionConfirm:          "Confirm",
+	actionPause:            "Pause",
+	actionToggleFullscreen: "ToggleFullscreen",
+	actionToggleHitboxes:   "ToggleHitboxes",
+	actionTextSubmit:       "TextSubmit",
+	actionTextDelete:       "TextDelete",
+}
+
+// 默认键盘绑定
+var defaultBindings = [actionCount][]sdl.Scancode{
+	actionMoveUp:           {sdl.ScancodeW, sdl.ScancodeUp},
+	actionMoveDown:         {sdl.ScancodeS, sdl.ScancodeDown},
+	actionMoveLeft:         {sdl.ScancodeA, sdl.ScancodeLeft},
+	actionMoveRight:        {sdl.ScancodeD, sdl.ScancodeRight},
+	actionFire:             {sdl.ScancodeSpace},
+	actionBomb:             {sdl.ScancodeK},
+	actionFocus:            {sdl.ScancodeLShift},
+	actionConfirm:          {sdl.ScancodeJ},
+	actionPause:            {sdl.ScancodeEscape},
+	actionToggleFullscreen: {sdl.ScancodeF4},
+	actionToggleHitboxes:   {sdl.ScancodeF3},
+	actionTextSubmit:       {sdl.ScancodeReturn},
+	actionTextDelete:       {sdl.ScancodeBackspace},
+}
+
+// 默认手柄按键绑定
+var defaultPadBindings = [actionCount][]sdl.GamepadButton{
+	actionMoveUp:     {sdl.GamepadButtonDpadUp},
+	actionMoveDown:   {sdl.GamepadButtonDpadDown},
+	actionMoveLeft:   {sdl.GamepadButtonDpadLeft},
+	actionMoveRight:  {sdl.GamepadButtonDpadRight},
+	actionFire:       {sdl.GamepadButtonSouth, sdl.GamepadButtonRightShoulder},
+	actionBomb:       {sdl.GamepadButtonEast, sdl.GamepadButtonLeftShoulder},
+	actionFocus:      {sdl.GamepadButtonWest},
+	actionConfirm:    {sdl.GamepadButtonSouth},
+	actionPause:      {sdl.GamepadButtonStart},
+	actionTextSubmit: {sdl.GamepadButtonStart},
+	actionTextDelete: {sdl.GamepadButtonEast},
+}
+
+// 配置文件中手柄按键的前缀
+const padBindingPrefix = "pad:"
+
+// 默认摇杆死区
+const defaultDeadzone = float32(0.25)
+
+// 动作到按键的绑定，每个动作可以绑定多个键盘按键和手柄按键
+type inputBindings struct {
+	keys    [actionCount][]sdl.Scancode
+	buttons [actionCount][]sdl.GamepadButton
+	// 摇杆死区，范围[0,1)
+	deadzone float32
+}
+
+// 创建默认按键绑定
+func newInputBindings() *inputBindings {
+	b := &inputBindings{deadzone: defaultDeadzone}
+	for a := action(0); a < actionCount; a++ {
+		b.bind(a, defaultBindings[a])
+		b.bindPad(a, defaultPadBindings[a])
+	}
+	return b
+}
+
+// 重新绑定动作的按键
+func (b *inputBindings) bind(a action, keys []sdl.Scancode) {
+	b.keys[a] = append([]sdl.Scancode(nil), keys...)
+}
+
+// 动作第一个绑定按键的名字，用于界面提示，没有绑定按键时返回"?"
+func (b *inputBindings) keyName(a action) string {
+	if keys := b.keys[a]; len(keys) > 0 {
+		return sdl.GetScancodeName(keys[0])
+	}
+	return "?"
+}
+
+// 重新绑定动作的手柄按键
+func (b *inputBindings) bindPad(a action, buttons []sdl.GamepadButton) {
+	b.buttons[a] = append([]sdl.GamepadButton(nil), buttons...)
+}
+
+// 动作当前是否按住
+func (b *inputBindings) isDown(a action) bool {
+	keyboardState := sdl.GetKeyboardState()
+	for _, key := range b.keys[a] {
+		if int(key) < len(keyboardState) && keyboardState[key] {
+			return true
+		}
+	}
+	for _, gamepad := range GetInstance().gamepads {
+		for _, button := range b.buttons[a] {
+			if sdl.GetGamepadButton(gamepad, button) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// 事件是否触发了动作
+func (b *inputBindings) isPressed(event sdl.Event, a action) bool {
+	switch event.Type() {
+	case sdl.EventKeyDown:
+		scancode := event.Key().Scancode
+		for _, key := range b.keys[a] {
+			if key == scancode {
+				return true
+			}
+		}
+	case sdl.EventGamepadButtonDown:
+		button := sdl.GamepadButton(event.GButton().Button)
+		for _, bound := range b.buttons[a] {
+			if bound == button {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// 读取左摇杆方向，所有手柄叠加，死区内为0，死区外重新映射到[0,1]
+func (b *inputBindings) stick() (float32, float32) {
+	var x, y float32
+	for _, gamepad := range GetInstance().gamepads {
+		x += float32(sdl.GetGamepadAxis(gamepad, sdl.GamepadAxisLeftX)) / 32767.0
+		y += float32(sdl.GetGamepadAxis(gamepad, sdl.GamepadAxisLeftY)) / 32767.0
+	}
+	length := float32(math.Sqrt(float64(x*x + y*y)))
+	if length <= b.deadzone {
+		return 0, 0
+	}
+	scale := (min(length, 1.0) - b.deadzone) / (1.0 - b.deadzone) / length
+	return x * scale, y * scale
+}
+
+// 根据名字查找动作
+func actionFromName(name string) (action, bool) {
+	for a := action(0); a < actionCount; a++ {
+		if actionNames[a] == name {
+			return a, true
+		}
+	}
+	return actionCount, false
+}
+
+// 载入按键绑定配置，每行格式为"动作 = 按键, 按键"，按键为SDL按键名字，#开头为注释
+// 手柄按键加上"pad:"前缀，例如"pad:a"，摇杆死区为"Deadzone = 0.25"
+// 配置文件中没有出现的动作保持默认绑定
+func (b *inputBindings) load(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("invalid binding line, %v:%v, %q", path, lineNum, line)
+		}
+		name = strings.TrimSpace(name)
+		if name == "Deadzone" {
+			deadzone, err := strconv.ParseFloat(strings.TrimSpace(value), 32)
+			if err != nil || deadzone < 0 || deadzone >= 1 {
+				return fmt.Errorf("invalid deadzone, %v:%v, %q", path, lineNum, strings.TrimSpace(value))
+			}
+			b.deadzone = float32(deadzone)
+			continue
+		}
+		a, ok := actionFromName(name)
+		if !ok {
+			return fmt.Errorf("unknown action, %v:%v, %q", path, lineNum, name)
+		}
+		var keys []sdl.Scancode
+		var buttons []sdl.GamepadButton
+		for _, keyName := range strings.Split(value, ",") {
+			keyName = strings.TrimSpace(keyName)
+			if keyName == "" {
+				continue
+			}
+			if buttonName, ok := strings.CutPrefix(keyName, padBindingPrefix); ok {
+				button := sdl.GetGamepadButtonFromString(buttonName)
+				if button == sdl.GamepadButtonInvalid {
+					return fmt.Errorf("unknown gamepad button, %v:%v, %q", path, lineNum, buttonName)
+				}
+				buttons = append(buttons, button)
+				continue
+			}
+			key := sdl.GetScancodeFromName(keyName)
+			if key == sdl.ScancodeUnknown {
+				return fmt.Errorf("unknown key, %v:%v, %q", path, lineNum, keyName)
+			}
+			keys = append(keys, key)
+		}
+		b.bind(a, keys)
+		b.bindPad(a, buttons)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read bindings, %v, %v", path, err)
+	}
+	return nil
+}
+
+// 保存按键绑定配置
+func (b *inputBindings) save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create bindings dir, %v, %v", path, err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create bindings, %v, %v", path, err)
+	}
+	defer file.Close()
+
+	fmt.Fprintln(file, "# 按键绑定，格式：动作 = 按键, 按键，手柄按键加上pad:前缀")
+	fmt.Fprintf(file, "Deadzone = %v\n", b.deadzone)
+	for a := action(0); a < actionCount; a++ {
+		names := make([]string, 0, len(b.keys[a])+len(b.buttons[a]))
+		for _, key := range b.keys[a] {
+			names = append(names, sdl.GetScancodeName(key))
+		}
+		for _, button := range b.buttons[a] {
+			names = append(names, padBindingPrefix+sdl.GetGamepadStringForButton(button))
+		}
+		fmt.Fprintf(file, "%v = %v\n", actionNames[a], strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// 单个逻辑帧的输入状态
+type inputState struct {
+	// 移动方向，每个分量范围[-1,1]
+	moveX float32
+	moveY float32
+	// 开火
+	fire bool
+	// 炸弹
+	bomb bool
+	// 低速精确移动
+	focus bool
+}
+
+// 输入源接口
+type inputSource interface {
+	// 读取当前逻辑帧的输入状态
+	poll() inputState
+}
+
+// 设备输入源，按动作绑定读取键盘和手柄
+type deviceInput struct{}
+
+var _ inputSource = deviceInput{}
+
+func (deviceInput) poll() inputState {
+	bindings := GetInstance().bindings
+	var state inputState
+	state.moveX, state.moveY = bindings.stick()
+	// 方向键优先于摇杆
+	if x := axisFromButtons(bindings.isDown(actionMoveLeft), bindings.isDown(actionMoveRight)); x != 0 {
+		state.moveX = x
+	}
+	if y := axisFromButtons(bindings.isDown(actionMoveUp), bindings.isDown(actionMoveDown)); y != 0 {
+		state.moveY = y
+	}
+	state.fire = bindings.isDown(actionFire)
+	state.bomb = bindings.isDown(actionBomb)
+	state.focus = bindings.isDown(actionFocus)
+	// 量化后再使用，保证录制的回放和实际模拟完全一致
+	return state.pack().unpack()
+}
+
+// 压缩后的输入状态，用于回放录制
+type packedInput struct {
+	// 移动方向，[-127,127]
+	moveX int8
+	moveY int8
+	// 按键位
+	buttons uint8
+}
+
+// 按键位
+const (
+	packedFire  uint8 = 1 << 0
+	packedBomb  uint8 = 1 << 1
+	packedFocus uint8 = 1 << 2
+)
+
+// 压缩输入状态
+func (i inputState) pack() packedInput {
+	packed := packedInput{
+		moveX: quantizeAxis(i.moveX),
+		moveY: quantizeAxis(i.moveY),
+	}
+	if i.fire {
+		packed.buttons |= packedFire
+	}
+	if i.bomb {
+		packed.buttons |= packedBomb
+	}
+	if i.focus {
+		packed.buttons |= packedFocus
+	}
+	return packed
+}
+
+// 还原输入状态
+func (p packedInput) unpack() inputState {
+	return inputState{
+		moveX: float32(p.moveX) / 127.0,
+		moveY: float32(p.moveY) / 127.0,
+		fire:  p.buttons&packedFire != 0,
+		bomb:  p.buttons&packedBomb != 0,
+		focus: p.buttons&packedFocus != 0,
+	}
+}
+
+// 轴向量化到[-127,127]
+func quantizeAxis(value float32) int8 {
+	value = max(-1.0, min(1.0, value))
+	return int8(math.Round(float64(value) * 127.0))
+}
+
+// 两个相反方向的按键合成一个轴向，同时按下时抵消
+func axisFromButtons(negative bool, positive bool) float32 {
+	var value float32
+	if negative {
+		value -= 1
+	}
+	if positive {
+		value += 1
+	}
+	return value
+}
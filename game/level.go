@@ -0,0 +1,363 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/SunshineZzzz/purego-sdl3/sdl"
+)
+
+// 关卡定义文件
+const levelDefsPath = "assets/data/levels.json"
+
+// 随机选择敌人类型的名字，按敌人类型的权重选择
+const randomEnemyName = "random"
+
+// 关卡提示显示时间，毫秒
+const (
+	levelIntroMs    = 2000
+	levelCompleteMs = 3000
+)
+
+// 生成定义
+type spawnDef struct {
+	// 相对波次开始的时间，毫秒
+	AtMs uint64 `json:"atMs"`
+	// 敌人类型名字，random表示按权重随机
+	Enemy string `json:"enemy"`
+	// 队形：single单个，line横排，column竖排，v字形
+	Formation string `json:"formation"`
+	// 数量
+	Count int32 `json:"count"`
+	// 队形中心的水平位置，相对窗口宽度[0,1]，不填时随机位置
+	X *float32 `json:"x"`
+	// 队形间距，像素
+	Spacing float32 `json:"spacing"`
+}
+
+// 波次定义
+type waveDef struct {
+	// 通关条件：allGone所有敌人被消灭或者离开屏幕，timeout到达时间
+	Clear string `json:"clear"`
+	// 超时时间，毫秒，allGone时可选，超时后也视为通过
+	TimeoutMs uint64 `json:"timeoutMs"`
+	// 生成列表，按时间排序
+	Spawns []spawnDef `json:"spawns"`
+}
+
+// 关卡定义
+type levelDef struct {
+	// 关卡名字
+	Name string `json:"name"`
+	// 所有波次通过后出场的Boss名字，可选
+	Boss string `json:"boss"`
+	// Boss在实体定义bosses中的下标，校验时由名字解析，没有Boss时为-1
+	bossIndex int
+	// 波次列表
+	Waves []waveDef `json:"waves"`
+}
+
+// 关卡列表定义
+type levelDefs struct {
+	Levels []levelDef `json:"levels"`
+}
+
+// 载入并校验关卡定义，敌人类型必须在实体定义中存在
+func loadLevelDefs(path string, entities *entityDefs) (*levelDefs, error) {
+	data, err := GetInstance().assets.readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read level defs, %v, %v", path, err)
+	}
+
+	defs := &levelDefs{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(defs); err != nil {
+		return nil, fmt.Errorf("failed to parse level defs, %v, %v", path, jsonErrorWithLine(data, err))
+	}
+
+	enemyNames := make(map[string]bool)
+	for _, enemy := range entities.Enemies {
+		enemyNames[enemy.Name] = true
+	}
+	bossIndexes := make(map[string]int)
+	for i, boss := range entities.Bosses {
+		bossIndexes[boss.Name] = i
+	}
+
+	v := &defValidator{path: path}
+	if len(defs.Levels) == 0 {
+		v.fail("levels", "must not be empty")
+	}
+	for i := range defs.Levels {
+		level := &defs.Levels[i]
+		levelName := fmt.Sprintf("levels[%v]", i)
+		if level.Name == "" {
+			v.fail(levelName+".name", "is required")
+		}
+		if len(level.Waves) == 0 {
+			v.fail(levelName+".waves", "must not be empty")
+		}
+		level.bossIndex = -1
+		if level.Boss != "" {
+			if index, ok := bossIndexes[level.Boss]; ok {
+				level.bossIndex = index
+			} else {
+				v.fail(levelName+".boss", "unknown boss, %q", level.Boss)
+			}
+		}
+		for j := range level.Waves {
+			wave := &level.Waves[j]
+			waveName := fmt.Sprintf("%v.waves[%v]", levelName, j)
+			switch wave.Clear {
+			case "allGone":
+			case "timeout":
+				v.positive(waveName+".timeoutMs", float32(wave.TimeoutMs))
+			default:
+				v.fail(waveName+".clear", "unknown clear condition, %q", wave.Clear)
+			}
+			if len(wave.Spawns) == 0 {
+				v.fail(waveName+".spawns", "must not be empty")
+			}
+			lastAt := uint64(0)
+			for k := range wave.Spawns {
+				spawn := &wave.Spawns[k]
+				spawnName := fmt.Sprintf("%v.spawns[%v]", waveName, k)
+				if spawn.Enemy != randomEnemyName && !enemyNames[spawn.Enemy] {
+					v.fail(spawnName+".enemy", "unknown enemy type, %q", spawn.Enemy)
+				}
+				if spawn.AtMs < lastAt {
+					v.fail(spawnName+".atMs", "must not be earlier than previous spawn, got %v", spawn.AtMs)
+				}
+				lastAt = spawn.AtMs
+				if spawn.Formation == "" {
+					spawn.Formation = "single"
+				}
+				if spawn.Count == 0 {
+					spawn.Count = 1
+				}
+				switch spawn.Formation {
+				case "single":
+				case "line", "column", "v":
+					v.positive(spawnName+".spacing", spawn.Spacing)
+					if spawn.X == nil {
+						v.fail(spawnName+".x", "is required for formation %q", spawn.Formation)
+					}
+				default:
+					v.fail(spawnName+".formation", "unknown formation, %q", spawn.Formation)
+				}
+				v.positive(spawnName+".count", float32(spawn.Count))
+				if spawn.X != nil && (*spawn.X < 0 || *spawn.X > 1) {
+					v.fail(spawnName+".x", "must be in [0,1], got %v", *spawn.X)
+				}
+			}
+		}
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	return defs, nil
+}
+
+// 关卡运行状态
+const (
+	// 显示关卡名字
+	levelStateIntro int32 = iota
+	// 执行波次
+	levelStateRunning
+	// Boss战
+	levelStateBoss
+	// 显示关卡完成
+	levelStateComplete
+	// 所有关卡完成
+	levelStateFinished
+)
+
+// 关卡运行器，按时间执行波次中的生成，判断波次和关卡是否完成
+type levelRunner struct {
+	defs *levelDefs
+	// 当前关卡
+	levelIndex int
+	// 当前波次
+	waveIndex int
+	// 当前波次的编号，全局递增，用于标记敌人属于哪个波次
+	waveID int32
+	// 下一个要执行的生成
+	spawnIndex int
+	// 当前状态
+	state int32
+	// 进入当前状态或者当前波次开始的时间，毫秒
+	stateStartTime uint64
+}
+
+// 从第一关开始
+func (l *levelRunner) start(defs *levelDefs, now uint64) {
+	l.defs = defs
+	l.levelIndex = 0
+	l.waveIndex = 0
+	l.waveID = 0
+	l.spawnIndex = 0
+	l.state = levelStateIntro
+	l.stateStartTime = now
+}
+
+// 当前关卡
+func (l *levelRunner) level() *levelDef {
+	return &l.defs.Levels[l.levelIndex]
+}
+
+// 当前波次
+func (l *levelRunner) wave() *waveDef {
+	return &l.level().Waves[l.waveIndex]
+}
+
+// 是否所有关卡完成
+func (l *levelRunner) isFinished() bool {
+	return l.state == levelStateFinished
+}
+
+// 更新关卡
+func (s *sceneMain) updateLevel() {
+	l := &s.level
+	now := s.currentTime()
+	switch l.state {
+	case levelStateIntro:
+		if now-l.stateStartTime >= levelIntroMs {
+			l.state = levelStateRunning
+			l.waveIndex = 0
+			s.startWave(now)
+		}
+	case levelStateRunning:
+		wave := l.wave()
+		elapsed := now - l.stateStartTime
+		for l.spawnIndex < len(wave.Spawns) && wave.Spawns[l.spawnIndex].AtMs <= elapsed {
+			s.executeSpawn(&wave.Spawns[l.spawnIndex])
+			l.spawnIndex++
+		}
+		if !s.isWaveCleared(elapsed) {
+			return
+		}
+		l.waveIndex++
+		if l.waveIndex < len(l.level().Waves) {
+			s.startWave(now)
+			return
+		}
+		if index := l.level().bossIndex; index >= 0 {
+			s.spawnBoss(s.bossTypes[index])
+			l.state = levelStateBoss
+			l.stateStartTime = now
+			return
+		}
+		l.state = levelStateComplete
+		l.stateStartTime = now
+	case levelStateBoss:
+		// Boss死亡动画结束后关卡完成
+		if s.boss != nil {
+			return
+		}
+		l.state = levelStateComplete
+		l.stateStartTime = now
+	case levelStateComplete:
+		if now-l.stateStartTime < levelCompleteMs {
+			return
+		}
+		l.levelIndex++
+		if l.levelIndex < len(l.defs.Levels) {
+			l.state = levelStateIntro
+			l.stateStartTime = now
+			return
+		}
+		l.levelIndex = len(l.defs.Levels) - 1
+		l.state = levelStateFinished
+	}
+}
+
+// 开始当前波次
+func (s *sceneMain) startWave(now uint64) {
+	s.level.waveID++
+	s.level.spawnIndex = 0
+	s.level.stateStartTime = now
+}
+
+// 当前波次是否通过
+func (s *sceneMain) isWaveCleared(elapsed uint64) bool {
+	wave := s.level.wave()
+	timeout := wave.TimeoutMs > 0 && elapsed >= wave.TimeoutMs
+	if wave.Clear == "timeout" {
+		return timeout
+	}
+	if timeout {
+		return true
+	}
+	if s.level.spawnIndex < len(wave.Spawns) {
+		return false
+	}
+	for e := range s.world.query(componentEnemyAI) {
+		if s.world.enemyAIs.at(e).wave == s.level.waveID {
+			return false
+		}
+	}
+	return true
+}
+
+// 执行一次生成
+func (s *sceneMain) executeSpawn(spawn *spawnDef) {
+	kind := s.findEnemyType(spawn.Enemy)
+	if spawn.X == nil {
+		for i := int32(0); i < spawn.Count; i++ {
+			s.spawnEnemyOfType(kind)
+		}
+		return
+	}
+
+	centerX := *spawn.X * s.fieldWidth
+	for i := int32(0); i < spawn.Count; i++ {
+		// 相对队形中心的偏移
+		index := float32(i) - float32(spawn.Count-1)/2
+		var dx, dy float32
+		switch spawn.Formation {
+		case "line":
+			dx = index * spawn.Spacing
+		case "column":
+			dy = -float32(i) * spawn.Spacing
+		case "v":
+			dx = index * spawn.Spacing
+			dy = -float32(math.Abs(float64(index))) * spawn.Spacing / 2
+		}
+		// 编队类型的敌人在每个位置生成整个编队
+		width := kind.groupWidth()
+		x := centerX + dx - width/2
+		x = max(0, min(s.fieldWidth-width, x))
+		s.spawnEnemyAt(kind, sdl.FPoint{X: x, Y: -kind.prefab.height + dy})
+	}
+}
+
+// 根据名字查找敌人类型，random时按权重随机
+func (s *sceneMain) findEnemyType(name string) *enemyType {
+	if name != randomEnemyName {
+		for _, kind := range s.enemyTypes {
+			if kind.name == name {
+				return kind
+			}
+		}
+	}
+	return s.enemyTypes[pickWeighted(s.rand, s.enemyWeights)]
+}
+
+// 渲染关卡提示
+func (s *sceneMain) renderLevel() {
+	switch s.level.state {
+	case levelStateIntro:
+		GetInstance().renderTextCentered(s.level.level().Name, 0.4, false)
+	case levelStateBoss:
+		if s.boss != nil && s.boss.isEntering() {
+			GetInstance().renderTextCentered("警告：Boss接近", 0.4, false)
+		}
+	case levelStateComplete:
+		GetInstance().renderTextCentered(s.level.level().Name+" 完成", 0.4, false)
+	case levelStateFinished:
+		GetInstance().renderTextCentered("全部关卡完成", 0.4, true)
+	}
+}
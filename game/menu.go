@@ -0,0 +1,35 @@
+package game
+
+import (
+	"github.com/SunshineZzzz/purego-sdl3/sdl"
+)
+
+// 菜单，上下移动选择，确认键选中
+type menu struct {
+	// 选项文字
+	items []string
+	// 当前选中的选项
+	selected int
+}
+
+// 处理菜单导航事件，确认时返回true
+func (m *menu) handleEvent(event sdl.Event) bool {
+	bindings := GetInstance().bindings
+	if bindings.isPressed(event, actionMoveUp) {
+		m.selected = (m.selected + len(m.items) - 1) % len(m.items)
+	}
+	if bindings.isPressed(event, actionMoveDown) {
+		m.selected = (m.selected + 1) % len(m.items)
+	}
+	return bindings.isPressed(event, actionConfirm)
+}
+
+// 渲染菜单，posY为第一项的位置，spacing为每项的间隔，都是相对窗口高度的比例
+func (m *menu) render(posY float32, spacing float32) {
+	for i, item := range m.items {
+		if i == m.selected {
+			item = "> " + item + " <"
+		}
+		GetInstance().renderTextCentered(item, posY+float32(i)*spacing, false)
+	}
+}
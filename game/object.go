@@ -14,6 +14,8 @@ type background struct {
 	height float32
 	// 偏移量
 	offset float32
+	// 上一逻辑帧的偏移量，用于渲染插值
+	lastOffset float32
 	// 速度
 	speed float32
 }
@@ -24,12 +26,22 @@ type player struct {
 	texture *sdl.Texture
 	// 位置
 	position sdl.FPoint
+	// 上一逻辑帧的位置，用于渲染插值
+	lastPosition sdl.FPoint
 	// 宽度
 	width float32
 	// 高度
 	height float32
 	// 速度
 	speed float32
+	// 低速模式的速度
+	focusSpeed float32
+	// 是否处于低速模式
+	focused bool
+	// 拾取物品的碰撞盒
+	hitbox hitbox
+	// 受到伤害的判定点
+	core hitbox
 	// 当前生命值
 	currentHealth int32
 	// 最大生命值
@@ -38,79 +50,70 @@ type player struct {
 	coolDown uint64
 	// 上次射击时间
 	lastShootTime uint64
+	// 护盾
+	shield powerUp
+	// 当前武器
+	weapon *weapon
+	// 当前武器等级，从0开始
+	weaponLevel int
+	// 剩余炸弹数量
+	bombs int32
+	// 炸弹数量上限
+	maxBombs int32
+	// 上一逻辑帧是否按住炸弹键，按下时才触发
+	bombHeld bool
+	// 无敌效果，受伤或者使用炸弹后进入
+	invincible powerUp
+	// 受伤后的无敌时间，毫秒
+	hitInvincibleMs uint64
+	// 受伤后闪红的时间，毫秒
+	hitFlashMs uint64
+	// 受伤时的击退速度
+	knockbackSpeed float32
+	// 当前击退速度
+	knockback sdl.FPoint
+	// 上次受伤的时间
+	hitTime uint64
 }
 
-// 玩家子弹
-type projectilePlayer struct {
-	texture *sdl.Texture
-	// 位置
-	position sdl.FPoint
-	// 宽度
-	width float32
-	// 高度
-	height float32
-	// 速度
-	speed float32
-	// 伤害
-	damage int32
-}
-
-// 敌机
-type enemy struct {
+// Boss
+type boss struct {
 	// 纹理
 	texture *sdl.Texture
 	// 位置
 	position sdl.FPoint
+	// 上一逻辑帧的位置，用于渲染插值
+	lastPosition sdl.FPoint
 	// 宽度
 	width float32
 	// 高度
 	height float32
-	// 速度
+	// 入场速度
 	speed float32
 	// 当前生命值
-	currentHealth int32
-	// 冷却时间
-	coolDown uint64
-	// 上次射击时间
-	lastShootTime uint64
-}
-
-// 敌人子弹
-type projectileEnemy struct {
-	// 纹理
-	texture *sdl.Texture
-	// 位置
-	position sdl.FPoint
-	// 方向
-	direction sdl.FPoint
-	// 宽度
-	width float32
-	// 高度
-	height float32
-	// 速度
-	speed float32
-	// 伤害
-	damage int32
-}
-
-// 爆炸
-type explosion struct {
-	// 序列帧动画对应的纹理
-	texture *sdl.Texture
-	// 位置
-	position sdl.FPoint
-	// 宽度
-	width float32
-	// 高度
-	height float32
-	// 当前帧
-	currentFrame float32
-	// 总帧数
-	totalFrame float32
-	// 开始时间
-	startTime uint64
-	// 动画帧率，1秒多少张图片，totalFrame/fps = 播放时间
-	fps uint32
+	currentHealth float32
+	// 最大生命值
+	maxHealth float32
+	// Boss类型
+	kind *bossType
+	// 入场完成后的时间，秒，用于左右摆动
+	age float32
+	// 入场完成时的水平位置，摆动以此为中心
+	originX float32
+	// 当前阶段
+	phase int
+	// 当前阶段每个攻击的状态
+	attacks []bossAttack
+	// 上次弱点被击中的时间，用于受击闪烁
+	weakHitTime uint64
+	// 是否正在播放死亡动画
+	dying bool
+	// 开始死亡动画的时间
+	deathStartTime uint64
+	// 上次死亡爆炸的时间
+	lastExplosionTime uint64
+	// 碰撞盒
+	hitbox hitbox
 }
 
 // 物品类型
@@ -120,24 +123,14 @@ const (
 	itemTypeLife itemType = iota
 	itemTypeShield
 	itemTypeTime
+	itemTypeWeapon
+	itemTypeBomb
 )
 
-// 物品
-type item struct {
-	// 纹理
-	texture *sdl.Texture
-	// 位置
-	position sdl.FPoint
-	// 方向
-	direction sdl.FPoint
-	// 宽度
-	width float32
-	// 高度
-	height float32
-	// 速度
-	speed float32
-	// 弹跳次数
-	bounceCount int32
-	// 物品类型
-	itemType itemType
+// 物品种类
+type itemKind struct {
+	// 物品预制体
+	prefab prefab
+	// 拾取效果
+	pickup pickup
 }
@@ -1,8 +1,8 @@
 package game
 
 import (
+	"bytes"
 	"fmt"
-	"os"
 	"unsafe"
 
 	"github.com/SunshineZzzz/purego-sdl3/sdl"
@@ -34,7 +34,7 @@ func unregisterOGGPlayer(id uint32) {
 type oggPlayer struct {
 	// SDL音频流
 	stream *sdl.AudioStream
-	// PCM音频数据
+	// PCM音频数据，和资源缓存共享，只读
 	audioData []byte
 	// 当前播放位置
 	dataPos int
@@ -49,14 +49,19 @@ type oggPlayer struct {
 	channels   int32
 }
 
-func newOggPlayer(soundFilePath string) (*oggPlayer, error) {
-	file, err := os.Open(soundFilePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open sound file, %v, %v", soundFilePath, err)
-	}
-	defer file.Close()
+// 解码后的OGG音乐，多个播放器共享
+type oggData struct {
+	// PCM音频数据，32位浮点
+	pcm []byte
+	// 采样率
+	sampleRate int32
+	// 声道数
+	channels int32
+}
 
-	oggReader, err := oggvorbis.NewReader(file)
+// 解码OGG文件数据，可以在后台协程调用
+func loadOgg(soundFilePath string, data []byte) (*oggData, error) {
+	oggReader, err := oggvorbis.NewReader(bytes.NewReader(data))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create oggvorbis reader, %v, %v", soundFilePath, err)
 	}
@@ -74,20 +79,29 @@ func newOggPlayer(soundFilePath string) (*oggPlayer, error) {
 		totalSamples += n
 	}
 
+	return &oggData{
+		pcm:        float32ToBytes(pcmData[:totalSamples]),
+		sampleRate: int32(oggReader.SampleRate()),
+		channels:   int32(oggReader.Channels()),
+	}, nil
+}
+
+// 由解码后的音乐创建播放器
+func newOggPlayer(data *oggData) (*oggPlayer, error) {
 	spec := &sdl.AudioSpec{
-		Freq:     int32(oggReader.SampleRate()),
-		Channels: int32(oggReader.Channels()),
+		Freq:     data.sampleRate,
+		Channels: data.channels,
 		Format:   sdl.AudioF32,
 	}
 
 	callback := sdl.NewAudioStreamCallback(audioCallback)
 	player := &oggPlayer{
-		audioData:  float32ToBytes(pcmData[:totalSamples]),
+		audioData:  data.pcm,
 		dataPos:    0,
 		isPlaying:  false,
 		loop:       false,
-		sampleRate: int32(oggReader.SampleRate()),
-		channels:   int32(oggReader.Channels()),
+		sampleRate: data.sampleRate,
+		channels:   data.channels,
 	}
 
 	player.id = registerOGGPlayer(player)
@@ -100,6 +114,7 @@ func newOggPlayer(soundFilePath string) (*oggPlayer, error) {
 	)
 
 	if player.stream == nil {
+		unregisterOGGPlayer(player.id)
 		return nil, fmt.Errorf("failed to open audio stream: %s", sdl.GetError())
 	}
 
@@ -160,7 +175,16 @@ func (p *oggPlayer) Pause() {
 		return
 	}
 	p.isPlaying = false
-	sdl.AudioStreamDevicePaused(p.stream)
+	sdl.PauseAudioStreamDevice(p.stream)
+}
+
+// 从暂停的位置继续播放
+func (p *oggPlayer) Resume() {
+	if p.stream == nil || p.id == 0 {
+		return
+	}
+	p.isPlaying = true
+	sdl.ResumeAudioStreamDevice(p.stream)
 }
 
 // 停止
@@ -170,7 +194,7 @@ func (p *oggPlayer) Stop() {
 	}
 	p.isPlaying = false
 	p.dataPos = 0
-	sdl.AudioStreamDevicePaused(p.stream)
+	sdl.PauseAudioStreamDevice(p.stream)
 	sdl.ClearAudioStream(p.stream)
 }
 
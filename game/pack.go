@@ -0,0 +1,377 @@
+package game
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// 资源包格式，整数都是小端序
+// 文件头：标识(4) 版本(uint32) 文件数量(uint32) 索引长度(uint32) 索引校验和(uint32)
+// 索引：每个文件一项，名字长度(uint16) 名字 压缩方式(uint8) 偏移(uint64) 压缩后长度(uint64) 原始长度(uint64) 原始数据校验和(uint32)
+// 数据：每个文件的数据依次存放，偏移从文件开头算起
+const (
+	// 资源包文件名
+	packFileName = "assets.pak"
+	// 文件头标识
+	packMagic = "SPAK"
+	// 格式版本
+	packVersion = 1
+	// 文件头长度
+	packHeaderSize = 20
+)
+
+// 资源包中文件的压缩方式
+const (
+	// 不压缩，压缩后没有变小的文件直接存放
+	packStored uint8 = iota
+	// DEFLATE压缩
+	packDeflate
+)
+
+// 打包时跳过的玩家数据
+var packSkipped = map[string]bool{
+	saveDataPath: true,
+	bindingsPath: true,
+	replayDir:    true,
+}
+
+// 资源包中的一个文件
+type packEntry struct {
+	// 压缩方式
+	method uint8
+	// 数据偏移
+	offset uint64
+	// 压缩后长度
+	packedSize uint64
+	// 原始长度
+	size uint64
+	// 原始数据的CRC32校验和
+	checksum uint32
+}
+
+// 资源包
+type assetPack struct {
+	// 资源包文件，用ReadAt读取，可以在多个协程中同时读取
+	file *os.File
+	// 按资源路径索引的文件
+	entries map[string]packEntry
+}
+
+// 打开资源包并校验索引
+func openPack(packPath string) (*assetPack, error) {
+	file, err := os.Open(packPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open asset pack, %v, %v", packPath, err)
+	}
+	p, err := readPackIndex(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read asset pack, %v, %v", packPath, err)
+	}
+	return p, nil
+}
+
+// 读取资源包的文件头和索引
+func readPackIndex(file *os.File) (*assetPack, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, packHeaderSize)
+	if _, err := file.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("header: %v", err)
+	}
+	if string(header[:4]) != packMagic {
+		return nil, fmt.Errorf("not an asset pack")
+	}
+	if version := binary.LittleEndian.Uint32(header[4:]); version != packVersion {
+		return nil, fmt.Errorf("unsupported version %v", version)
+	}
+	count := binary.LittleEndian.Uint32(header[8:])
+	indexSize := binary.LittleEndian.Uint32(header[12:])
+	if int64(indexSize) > info.Size()-packHeaderSize {
+		return nil, fmt.Errorf("index out of range")
+	}
+	index := make([]byte, indexSize)
+	if _, err := file.ReadAt(index, packHeaderSize); err != nil {
+		return nil, fmt.Errorf("index: %v", err)
+	}
+	if crc32.ChecksumIEEE(index) != binary.LittleEndian.Uint32(header[16:]) {
+		return nil, fmt.Errorf("index checksum mismatch")
+	}
+
+	p := &assetPack{file: file, entries: make(map[string]packEntry, count)}
+	reader := bytes.NewReader(index)
+	for i := uint32(0); i < count; i++ {
+		var nameLen uint16
+		if err := binary.Read(reader, binary.LittleEndian, &nameLen); err != nil {
+			return nil, fmt.Errorf("index entry %v: %v", i, err)
+		}
+		name := make([]byte, nameLen)
+		if _, err := io.ReadFull(reader, name); err != nil {
+			return nil, fmt.Errorf("index entry %v: %v", i, err)
+		}
+		var entry packEntry
+		fields := []any{&entry.method, &entry.offset, &entry.packedSize, &entry.size, &entry.checksum}
+		for _, field := range fields {
+			if err := binary.Read(reader, binary.LittleEndian, field); err != nil {
+				return nil, fmt.Errorf("index entry %v: %v", i, err)
+			}
+		}
+		if entry.offset+entry.packedSize > uint64(info.Size()) {
+			return nil, fmt.Errorf("data out of range, %v", string(name))
+		}
+		p.entries[string(name)] = entry
+	}
+	return p, nil
+}
+
+// 资源包中是否有该文件
+func (p *assetPack) has(name string) bool {
+	_, ok := p.entries[name]
+	return ok
+}
+
+// 读取并解压文件，校验长度和校验和
+func (p *assetPack) read(name string) ([]byte, error) {
+	entry, ok := p.entries[name]
+	if !ok {
+		return nil, fmt.Errorf("not in asset pack, %v", name)
+	}
+	packed := make([]byte, entry.packedSize)
+	if _, err := p.file.ReadAt(packed, int64(entry.offset)); err != nil {
+		return nil, fmt.Errorf("failed to read asset pack, %v, %v", name, err)
+	}
+	data := packed
+	if entry.method == packDeflate {
+		reader := flate.NewReader(bytes.NewReader(packed))
+		data = make([]byte, entry.size)
+		_, err := io.ReadFull(reader, data)
+		reader.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress asset, %v, %v", name, err)
+		}
+	}
+	if uint64(len(data)) != entry.size || crc32.ChecksumIEEE(data) != entry.checksum {
+		return nil, fmt.Errorf("asset checksum mismatch, %v", name)
+	}
+	return data, nil
+}
+
+// 关闭资源包
+func (p *assetPack) close() {
+	p.file.Close()
+}
+
+// 把dir目录打包到output，资源路径以目录名开头，例如assets/image/a.png，跳过玩家数据
+// 返回打包的文件数量
+func BuildPack(dir string, output string) (int, error) {
+	type packFile struct {
+		name  string
+		entry packEntry
+	}
+	var files []packFile
+	var blobs bytes.Buffer
+	base := filepath.Base(filepath.Clean(dir))
+	err := filepath.WalkDir(dir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		name := path.Join(base, filepath.ToSlash(rel))
+		if packSkipped[name] {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		entry, packed, err := packData(data)
+		if err != nil {
+			return fmt.Errorf("failed to compress, %v, %v", filePath, err)
+		}
+		entry.offset = uint64(blobs.Len())
+		blobs.Write(packed)
+		files = append(files, packFile{name: name, entry: entry})
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read assets, %v, %v", dir, err)
+	}
+
+	// 索引长度确定后才知道数据的起始偏移
+	indexSize := 0
+	for _, f := range files {
+		indexSize += 2 + len(f.name) + 1 + 8*3 + 4
+	}
+	dataOffset := uint64(packHeaderSize + indexSize)
+	var index bytes.Buffer
+	for _, f := range files {
+		binary.Write(&index, binary.LittleEndian, uint16(len(f.name)))
+		index.WriteString(f.name)
+		binary.Write(&index, binary.LittleEndian, f.entry.method)
+		binary.Write(&index, binary.LittleEndian, dataOffset+f.entry.offset)
+		binary.Write(&index, binary.LittleEndian, f.entry.packedSize)
+		binary.Write(&index, binary.LittleEndian, f.entry.size)
+		binary.Write(&index, binary.LittleEndian, f.entry.checksum)
+	}
+
+	header := make([]byte, packHeaderSize)
+	copy(header, packMagic)
+	binary.LittleEndian.PutUint32(header[4:], packVersion)
+	binary.LittleEndian.PutUint32(header[8:], uint32(len(files)))
+	binary.LittleEndian.PutUint32(header[12:], uint32(index.Len()))
+	binary.LittleEndian.PutUint32(header[16:], crc32.ChecksumIEEE(index.Bytes()))
+
+	out := make([]byte, 0, packHeaderSize+index.Len()+blobs.Len())
+	out = append(out, header...)
+	out = append(out, index.Bytes()...)
+	out = append(out, blobs.Bytes()...)
+	if err := os.WriteFile(output, out, 0644); err != nil {
+		return 0, fmt.Errorf("failed to write asset pack, %v, %v", output, err)
+	}
+	return len(files), nil
+}
+
+// 压缩一个文件，压缩后没有变小时直接存放
+func packData(data []byte) (packEntry, []byte, error) {
+	entry := packEntry{method: packStored, size: uint64(len(data)), checksum: crc32.ChecksumIEEE(data)}
+	var compressed bytes.Buffer
+	writer, err := flate.NewWriter(&compressed, flate.BestCompression)
+	if err != nil {
+		return entry, nil, err
+	}
+	writer.Write(data)
+	if err := writer.Close(); err != nil {
+		return entry, nil, err
+	}
+	packed := data
+	if compressed.Len() < len(data) {
+		entry.method = packDeflate
+		packed = compressed.Bytes()
+	}
+	entry.packedSize = uint64(len(packed))
+	return entry, packed, nil
+}
+
+// 读取资源包中的所有文件并校验，返回文件数量
+func CheckPack(packPath string) (int, error) {
+	p, err := openPack(packPath)
+	if err != nil {
+		return 0, err
+	}
+	defer p.close()
+	for name := range p.entries {
+		if _, err := p.read(name); err != nil {
+			return 0, err
+		}
+	}
+	return len(p.entries), nil
+}
+
+// 查找资源，优先使用资源包，其次使用散装的资源目录，依次在工作目录和可执行文件所在目录查找
+func (m *assetManager) openSource() {
+	dirs := []string{"."}
+	if exe, err := os.Executable(); err == nil {
+		dirs = append(dirs, filepath.Dir(exe))
+	}
+	m.fsys = os.DirFS(".")
+	m.dataDir = "."
+	for _, dir := range dirs {
+		packPath := filepath.Join(dir, packFileName)
+		if _, err := os.Stat(packPath); err == nil {
+			m.fsys = os.DirFS(dir)
+			m.dataDir = dir
+			pack, err := openPack(packPath)
+			if err != nil {
+				// 资源包损坏时继续使用散装文件
+				fmt.Println(err)
+				return
+			}
+			m.pack = pack
+			return
+		}
+		if info, err := os.Stat(filepath.Join(dir, "assets")); err == nil && info.IsDir() {
+			m.fsys = os.DirFS(dir)
+			m.dataDir = dir
+			return
+		}
+	}
+}
+
+// 使用指定的文件系统读取资源，不再查找资源包和资源目录，例如嵌入到可执行文件中的资源
+// 玩家数据保存在用户配置目录，取不到时保存在可执行文件所在目录
+// 必须在读取任何资源之前调用
+func SetAssetFS(fsys fs.FS) {
+	m := GetInstance().assets
+	m.sourceOnce.Do(func() {
+		m.fsys = fsys
+		m.dataDir = userDataDir()
+	})
+}
+
+// 用户数据目录，依次尝试用户配置目录和可执行文件所在目录，都取不到时使用工作目录
+func userDataDir() string {
+	if dir, err := os.UserConfigDir(); err == nil {
+		return filepath.Join(dir, "sdlshoot")
+	}
+	if exe, err := os.Executable(); err == nil {
+		return filepath.Dir(exe)
+	}
+	return "."
+}
+
+// 读取资源文件，资源包中没有时读取散装文件，方便开发时添加资源，可以在后台协程调用
+func (m *assetManager) readFile(name string) ([]byte, error) {
+	m.sourceOnce.Do(m.openSource)
+	if m.pack != nil && m.pack.has(name) {
+		return m.pack.read(name)
+	}
+	data, err := fs.ReadFile(m.fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read asset, %v, %v", name, err)
+	}
+	return data, nil
+}
+
+// 玩家数据文件的路径，name为相对资源目录的路径，例如assets/save.dat
+// 和读取资源使用同一个目录，不受工作目录影响
+func (m *assetManager) dataPath(name string) string {
+	m.sourceOnce.Do(m.openSource)
+	return filepath.Join(m.dataDir, filepath.FromSlash(name))
+}
+
+// 资源文件是否存在
+func (m *assetManager) exists(name string) bool {
+	m.sourceOnce.Do(m.openSource)
+	if m.pack != nil && m.pack.has(name) {
+		return true
+	}
+	_, err := fs.Stat(m.fsys, name)
+	return err == nil
+}
+
+// 关闭资源包
+func (m *assetManager) closeSource() {
+	if m.pack != nil {
+		m.pack.close()
+		m.pack = nil
+	}
+}
@@ -0,0 +1,152 @@
+package game
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 测试用的资源文件，一个可以压缩，一个随机数据直接存放
+func writeTestAssets(t *testing.T) (string, map[string][]byte) {
+	t.Helper()
+	random := make([]byte, 4096)
+	rand.New(rand.NewSource(1)).Read(random)
+	files := map[string][]byte{
+		"assets/data/levels.json": bytes.Repeat([]byte(`{"levels": []}`), 200),
+		"assets/image/noise.bin":  random,
+	}
+	dir := filepath.Join(t.TempDir(), "assets")
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(name, "assets/")))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir, files
+}
+
+// 把测试资源打包，返回资源包路径和原文件内容
+func buildTestPack(t *testing.T) (string, map[string][]byte) {
+	t.Helper()
+	dir, files := writeTestAssets(t)
+	output := filepath.Join(t.TempDir(), packFileName)
+	count, err := BuildPack(dir, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != len(files) {
+		t.Fatalf("packed %v files, want %v", count, len(files))
+	}
+	return output, files
+}
+
+// 打包后读取，内容和原文件一致
+func TestPackRoundTrip(t *testing.T) {
+	output, files := buildTestPack(t)
+	p, err := openPack(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.close()
+	for name, want := range files {
+		got, err := p.read(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("content mismatch, %v", name)
+		}
+	}
+	if p.entries["assets/data/levels.json"].method != packDeflate {
+		t.Error("compressible file not deflated")
+	}
+	if p.entries["assets/image/noise.bin"].method != packStored {
+		t.Error("random file not stored")
+	}
+}
+
+// 翻转资源包中的一个字节
+func flipPackByte(t *testing.T, path string, offset int64) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[offset] ^= 0xff
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 数据损坏时读取该文件返回校验和错误
+func TestPackBlobCorruption(t *testing.T) {
+	output, _ := buildTestPack(t)
+	p, err := openPack(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := p.entries["assets/image/noise.bin"]
+	p.close()
+
+	flipPackByte(t, output, int64(entry.offset+entry.packedSize/2))
+	p, err = openPack(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.close()
+	if _, err := p.read("assets/image/noise.bin"); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Errorf("corrupted blob read error, %v", err)
+	}
+	if _, err := p.read("assets/data/levels.json"); err != nil {
+		t.Errorf("intact file unreadable, %v", err)
+	}
+	if _, err := CheckPack(output); err == nil {
+		t.Error("check passed on corrupted pack")
+	}
+}
+
+// 索引损坏时打开资源包返回校验和错误
+func TestPackIndexCorruption(t *testing.T) {
+	output, _ := buildTestPack(t)
+	flipPackByte(t, output, packHeaderSize+2)
+	if _, err := openPack(output); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Errorf("corrupted index open error, %v", err)
+	}
+}
+
+// 资源包损坏时读取散装文件
+func TestCorruptPackFallsBackToFiles(t *testing.T) {
+	output, files := buildTestPack(t)
+	flipPackByte(t, output, packHeaderSize+2)
+	dir, _ := writeTestAssets(t)
+	root := filepath.Dir(dir)
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, packFileName), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(root)
+
+	m := newAssetManager()
+	defer m.closeSource()
+	for name, want := range files {
+		got, err := m.readFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("content mismatch, %v", name)
+		}
+	}
+	if m.pack != nil {
+		t.Error("corrupted pack still in use")
+	}
+}
@@ -0,0 +1,254 @@
+package game
+
+import (
+	"math"
+
+	"github.com/SunshineZzzz/purego-sdl3/sdl"
+)
+
+// 发射器类型
+type emitterKind int32
+
+const (
+	// 一圈均匀分布
+	emitterRing emitterKind = iota
+	// 扇形
+	emitterSpread
+	// 螺旋，每次发射后旋转
+	emitterSpiral
+	// 瞄准玩家连射
+	emitterAimedBurst
+	// 扇形范围内随机角度和速度
+	emitterScatter
+)
+
+var emitterKinds = map[string]emitterKind{
+	"ring":       emitterRing,
+	"spread":     emitterSpread,
+	"spiral":     emitterSpiral,
+	"aimedBurst": emitterAimedBurst,
+	"scatter":    emitterScatter,
+}
+
+// 子弹行为，同一弹幕的所有子弹共享
+type bulletBehavior struct {
+	// 加速度，像素每二次方秒，负数减速
+	acceleration float32
+	// 最大速度，0表示不限制
+	maxSpeed float32
+	// 最小速度，减速到此为止
+	minSpeed float32
+	// 角速度，弧度每秒
+	angularVelocity float32
+	// 追踪玩家的最大转向速度，弧度每秒
+	homingRate float32
+	// 追踪持续时间，秒
+	homingTime float32
+	// 延迟启动时间，秒，期间子弹停在原地
+	delay float32
+	// 启动时是否重新瞄准玩家
+	aimOnActivate bool
+}
+
+// 弹幕
+type bulletPattern struct {
+	// 名字
+	name string
+	// 发射器类型
+	emitter emitterKind
+	// 每次发射的子弹数量，aimedBurst为连射数量
+	count int32
+	// 扇形角度，弧度
+	spread float64
+	// 每次发射旋转的角度，弧度
+	rotate float64
+	// 连射间隔，毫秒
+	interval uint64
+	// 子弹初速度
+	speed float32
+	// 子弹生成位置离发射中心的距离
+	radius float32
+	// 随机附加速度范围（scatter）
+	speedRange float32
+	// 是否以玩家方向为中心，否则以正下方为中心
+	aimed bool
+	// 子弹行为
+	behavior bulletBehavior
+}
+
+// 由定义创建弹幕
+func newBulletPattern(def *patternDef) *bulletPattern {
+	toRad := math.Pi / 180
+	return &bulletPattern{
+		name:       def.Name,
+		emitter:    emitterKinds[def.Emitter],
+		count:      def.Count,
+		spread:     float64(def.SpreadDeg) * toRad,
+		rotate:     float64(def.RotateDeg) * toRad,
+		interval:   def.IntervalMs,
+		speed:      def.Speed,
+		radius:     def.Radius,
+		speedRange: def.SpeedRange,
+		aimed:      def.Aimed,
+		behavior: bulletBehavior{
+			acceleration:    def.Bullet.Acceleration,
+			maxSpeed:        def.Bullet.MaxSpeed,
+			minSpeed:        def.Bullet.MinSpeed,
+			angularVelocity: def.Bullet.AngularVelocityDeg * float32(toRad),
+			homingRate:      def.Bullet.HomingDegPerSec * float32(toRad),
+			homingTime:      float32(def.Bullet.HomingMs) / 1000,
+			delay:           float32(def.Bullet.DelayMs) / 1000,
+			aimOnActivate:   def.Bullet.AimOnActivate,
+		},
+	}
+}
+
+// 发射器，保存弹幕在多次发射之间的状态
+type emitter struct {
+	pattern *bulletPattern
+	// 当前角度偏移，弧度（spiral）
+	angle float64
+	// 剩余连射数量（aimedBurst）
+	burstLeft int32
+	// 上次连射时间（aimedBurst）
+	burstTime uint64
+}
+
+// 是否还在连射
+func (em *emitter) busy() bool {
+	return em.burstLeft > 0
+}
+
+// 从origin朝玩家中心的角度，弧度
+func (s *sceneMain) angleToPlayer(origin sdl.FPoint) float64 {
+	x := s.player.position.X + s.player.width/2 - origin.X
+	y := s.player.position.Y + s.player.height/2 - origin.Y
+	return math.Atan2(float64(y), float64(x))
+}
+
+// 发射一次弹幕，origin为发射中心
+func (s *sceneMain) emit(em *emitter, origin sdl.FPoint, currentTime uint64) {
+	pattern := em.pattern
+	center := math.Pi / 2
+	if pattern.aimed {
+		center = s.angleToPlayer(origin)
+	}
+	switch pattern.emitter {
+	case emitterRing, emitterSpiral:
+		step := 2 * math.Pi / float64(pattern.count)
+		for i := int32(0); i < pattern.count; i++ {
+			s.shootBullet(pattern, origin, center+em.angle+step*float64(i), pattern.speed)
+		}
+		if pattern.emitter == emitterSpiral {
+			em.angle += pattern.rotate
+		}
+	case emitterSpread:
+		start := center
+		step := 0.0
+		if pattern.count > 1 {
+			start -= pattern.spread / 2
+			step = pattern.spread / float64(pattern.count-1)
+		}
+		for i := int32(0); i < pattern.count; i++ {
+			s.shootBullet(pattern, origin, start+step*float64(i), pattern.speed)
+		}
+	case emitterScatter:
+		for i := int32(0); i < pattern.count; i++ {
+			angle := center + (s.rand.Float64()-0.5)*pattern.spread
+			speed := pattern.speed + s.rand.Float32()*pattern.speedRange
+			s.shootBullet(pattern, origin, angle, speed)
+		}
+	case emitterAimedBurst:
+		em.burstLeft = pattern.count
+		em.burstTime = currentTime
+		s.shootBullet(pattern, origin, s.angleToPlayer(origin), pattern.speed)
+		em.burstLeft--
+	}
+	s.playSound("enemy_shoot")
+}
+
+// 继续未完成的连射
+func (s *sceneMain) updateEmitter(em *emitter, origin sdl.FPoint, currentTime uint64) {
+	if !em.busy() || currentTime-em.burstTime < em.pattern.interval {
+		return
+	}
+	s.shootBullet(em.pattern, origin, s.angleToPlayer(origin), em.pattern.speed)
+	em.burstTime = currentTime
+	em.burstLeft--
+}
+
+// 发射一颗敌人子弹，angle为弧度，0朝右，顺时针增加
+func (s *sceneMain) shootBullet(pattern *bulletPattern, origin sdl.FPoint, angle float64, speed float32) {
+	w := s.world
+	direction := sdl.FPoint{X: float32(math.Cos(angle)), Y: float32(math.Sin(angle))}
+	position := sdl.FPoint{
+		X: origin.X + direction.X*pattern.radius - s.bulletPrefab.width/2,
+		Y: origin.Y + direction.Y*pattern.radius - s.bulletPrefab.height/2,
+	}
+	e := s.spawn(&s.bulletPrefab, position)
+	v := w.velocities.add(w, e)
+	*v = velocity{direction: direction, speed: speed}
+	// 延迟启动的子弹先停在原地
+	if pattern.behavior.delay > 0 {
+		v.speed = 0
+	}
+	*w.steerings.add(w, e) = steering{behavior: &pattern.behavior, speed: speed}
+}
+
+// 按子弹行为更新速度和方向，由移动系统移动，target为追踪目标
+func (st *steering) step(t *transform, v *velocity, deltaTime float32, target sdl.FPoint) {
+	b := st.behavior
+	lastAge := st.age
+	st.age += deltaTime
+	if st.age < b.delay {
+		return
+	}
+	center := t.center()
+	if lastAge < b.delay {
+		// 启动
+		v.speed = st.speed
+		if b.aimOnActivate {
+			v.direction = directionTo(center, target, v.direction)
+		}
+	}
+	active := st.age - b.delay
+
+	if b.acceleration != 0 {
+		v.speed += b.acceleration * deltaTime
+		if b.maxSpeed > 0 && v.speed > b.maxSpeed {
+			v.speed = b.maxSpeed
+		}
+		if v.speed < b.minSpeed {
+			v.speed = b.minSpeed
+		}
+	}
+	turn := b.angularVelocity * deltaTime
+	if b.homingRate > 0 && active < b.homingTime {
+		// 朝目标方向转向，单帧转角不超过最大转向速度
+		diff := turnAngle(v.direction, directionTo(center, target, v.direction))
+		limit := b.homingRate * deltaTime
+		turn += max(-limit, min(limit, diff))
+	}
+	if turn != 0 {
+		v.direction = rotatePoint(v.direction, turn)
+	}
+}
+
+// 更新所有敌人子弹的速度和方向
+func (s *sceneMain) steeringSystem(deltaTime float32) {
+	w := s.world
+	target := s.playerCenter()
+	for e := range w.query(componentTransform | componentVelocity | componentSteering) {
+		w.steerings.at(e).step(w.transforms.at(e), w.velocities.at(e), deltaTime, target)
+	}
+}
+
+// 清除所有敌人子弹，即没有生命值的敌方碰撞体
+func (s *sceneMain) clearEnemyProjectiles() {
+	w := s.world
+	for e := range w.query(componentCollider | componentTeam) {
+		if *w.teams.at(e) == teamEnemy && !w.has(e, componentHealth) {
+			w.destroy(e)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package game
+
+import "iter"
+
+// 对象池每块的对象数量
+const poolChunkSize = 256
+
+// 对象句柄，对象释放后旧句柄失效，即使槽位被重新使用
+type handle struct {
+	// 槽位下标
+	index int32
+	// 槽位的代数，每次分配加一，0表示无效句柄
+	generation uint32
+}
+
+// 对象池，按块分配存储，对象地址在池的生命周期内不变
+// 释放的槽位放入空闲列表重复使用，稳定运行时不再分配内存
+type pool[T any] struct {
+	// 存储块
+	chunks [][]T
+	// 每个槽位的代数
+	generations []uint32
+	// 每个槽位是否存活
+	alive []bool
+	// 空闲槽位
+	free []int32
+	// 已经释放但还在order中的槽位，从order中移除后才能重新使用
+	released []int32
+	// 存活槽位的分配顺序，释放的槽位在下次遍历前移除
+	order []int32
+	// 正在进行的遍历数量，遍历中不整理order
+	iterating int
+}
+
+// 创建对象池
+func newPool[T any]() *pool[T] {
+	return &pool[T]{}
+}
+
+// 槽位中的对象
+func (p *pool[T]) slot(index int32) *T {
+	return &p.chunks[index/poolChunkSize][index%poolChunkSize]
+}
+
+// 分配一个对象，对象保留上次使用时的内容，由调用者覆盖
+func (p *pool[T]) alloc() (handle, *T) {
+	var index int32
+	if n := len(p.free); n > 0 {
+		index = p.free[n-1]
+		p.free = p.free[:n-1]
+	} else {
+		index = int32(len(p.generations))
+		if int(index)%poolChunkSize == 0 {
+			p.chunks = append(p.chunks, make([]T, poolChunkSize))
+		}
+		p.generations = append(p.generations, 0)
+		p.alive = append(p.alive, false)
+	}
+	p.generations[index]++
+	if p.generations[index] == 0 {
+		p.generations[index] = 1
+	}
+	p.alive[index] = true
+	p.order = append(p.order, index)
+	return handle{index: index, generation: p.generations[index]}, p.slot(index)
+}
+
+// 句柄指向的对象是否存活
+func (p *pool[T]) valid(h handle) bool {
+	return h.generation != 0 && int(h.index) < len(p.generations) &&
+		p.alive[h.index] && p.generations[h.index] == h.generation
+}
+
+// 根据句柄取得对象，句柄失效时返回nil
+func (p *pool[T]) get(h handle) *T {
+	if !p.valid(h) {
+		return nil
+	}
+	return p.slot(h.index)
+}
+
+// 释放对象，句柄失效时返回false
+func (p *pool[T]) release(h handle) bool {
+	if !p.valid(h) {
+		return false
+	}
+	p.alive[h.index] = false
+	p.released = append(p.released, h.index)
+	return true
+}
+
+// 释放所有对象
+func (p *pool[T]) clear() {
+	for _, index := range p.order {
+		if p.alive[index] {
+			p.alive[index] = false
+			p.free = append(p.free, index)
+		}
+	}
+	p.free = append(p.free, p.released...)
+	p.released = p.released[:0]
+	p.order = p.order[:0]
+}
+
+// 存活对象的数量
+func (p *pool[T]) len() int {
+	return len(p.order) - len(p.released)
+}
+
+// 从order中移除已经释放的槽位，保持分配顺序
+func (p *pool[T]) compact() {
+	if len(p.released) == 0 || p.iterating > 0 {
+		return
+	}
+	count := 0
+	for _, index := range p.order {
+		if p.alive[index] {
+			p.order[count] = index
+			count++
+		}
+	}
+	p.order = p.order[:count]
+	p.free = append(p.free, p.released...)
+	p.released = p.released[:0]
+}
+
+// 按分配顺序遍历存活对象，遍历中可以释放对象和清空对象池，遍历中新分配的对象不会被遍历到
+func (p *pool[T]) all() iter.Seq2[handle, *T] {
+	return func(yield func(handle, *T) bool) {
+		p.compact()
+		p.iterating++
+		defer func() { p.iterating-- }()
+		count := len(p.order)
+		for i := 0; i < count && i < len(p.order); i++ {
+			index := p.order[i]
+			if !p.alive[index] {
+				continue
+			}
+			if !yield(handle{index: index, generation: p.generations[index]}, p.slot(index)) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package game
+
+import (
+	"math"
+	"testing"
+)
+
+// 测试用的输入，持续开火并左右来回移动
+type sweepInput struct {
+	// 已经读取的逻辑帧数
+	ticks int
+}
+
+func (i *sweepInput) poll() inputState {
+	i.ticks++
+	state := inputState{moveX: 1, fire: true}
+	if i.ticks/240%2 == 1 {
+		state.moveX = -1
+	}
+	return state
+}
+
+// 稳定运行时主场景的逻辑帧不分配内存：生成和移除敌人、子弹、爆炸和物品，遍历和碰撞检测
+func TestSceneMainTickZeroAllocs(t *testing.T) {
+	s := newHeadlessScene(t)
+	s.input = &sweepInput{}
+	g := GetInstance()
+	// 玩家一直无敌，测试期间不会死亡结束
+	s.player.invincible.start(0, math.MaxUint32, 0)
+	// 预热到对象池、组件存储和各种缓冲区不再增长
+	for i := 0; i < 120*60; i++ {
+		s.update(g.deltaTime)
+	}
+	if s.world.entities.len() == 0 {
+		t.Fatal("no entities alive after warm-up")
+	}
+	if allocs := testing.AllocsPerRun(600, func() { s.update(g.deltaTime) }); allocs != 0 {
+		t.Errorf("steady state tick allocated %v times", allocs)
+	}
+	if s.isDead {
+		t.Error("player died during test")
+	}
+}
+
+// 旧句柄在槽位重新使用后失效
+func TestPoolHandleGeneration(t *testing.T) {
+	p := newPool[int32]()
+	h, value := p.alloc()
+	*value = 1
+	if !p.release(h) {
+		t.Fatal("release of live handle failed")
+	}
+	for range p.all() {
+	}
+	reused, _ := p.alloc()
+	if reused.index != h.index {
+		t.Fatalf("released slot not reused, %v, %v", h.index, reused.index)
+	}
+	if p.get(h) != nil || p.release(h) {
+		t.Error("stale handle still valid")
+	}
+	if p.get(reused) == nil {
+		t.Error("reused handle not valid")
+	}
+}
+
+// 分配、释放一半、遍历的循环
+func BenchmarkPool(b *testing.B) {
+	p := newPool[transform]()
+	handles := make([]handle, 0, 1024)
+	b.ReportAllocs()
+	for b.Loop() {
+		for i := 0; i < 1024; i++ {
+			h, t := p.alloc()
+			t.position.X = float32(i)
+			handles = append(handles, h)
+		}
+		for i := 0; i < len(handles); i += 2 {
+			p.release(handles[i])
+		}
+		sum := float32(0)
+		for _, t := range p.all() {
+			sum += t.position.X
+		}
+		p.clear()
+		handles = handles[:0]
+	}
+}
@@ -0,0 +1,94 @@
+package game
+
+import (
+	"github.com/SunshineZzzz/purego-sdl3/sdl"
+)
+
+// 效果结束前闪烁的间隔，毫秒
+const powerUpBlinkMs = 100
+
+var itemTypes = map[string]itemType{
+	"life":   itemTypeLife,
+	"shield": itemTypeShield,
+	"time":   itemTypeTime,
+	"weapon": itemTypeWeapon,
+	"bomb":   itemTypeBomb,
+}
+
+// 限时效果
+type powerUp struct {
+	// 结束时间，毫秒
+	endTime uint64
+	// 结束前闪烁提示的时间，毫秒
+	warn uint64
+}
+
+// 开始效果，已有效果时重新计时
+func (p *powerUp) start(currentTime uint64, duration uint64, warn uint64) {
+	p.endTime = currentTime + duration
+	p.warn = warn
+}
+
+// 效果是否有效
+func (p *powerUp) isActive(currentTime uint64) bool {
+	return currentTime < p.endTime
+}
+
+// 效果是否需要显示，快结束时闪烁
+func (p *powerUp) isVisible(currentTime uint64) bool {
+	if !p.isActive(currentTime) {
+		return false
+	}
+	if p.endTime-currentTime > p.warn {
+		return true
+	}
+	return (currentTime/powerUpBlinkMs)%2 == 0
+}
+
+// 敌人时间，毫秒，时间减速时比模拟时钟走得慢
+func (s *sceneMain) enemyTime() uint64 {
+	return s.enemyClock / 1000000
+}
+
+// 敌人和敌人子弹使用的时间缩放
+func (s *sceneMain) enemyTimeScale() float32 {
+	if s.timeSlow.isActive(s.currentTime()) {
+		return s.timeScale
+	}
+	return 1
+}
+
+// 护盾是否有效，有效时吸收所有伤害
+func (s *sceneMain) isShielded() bool {
+	return s.player.shield.isActive(s.currentTime())
+}
+
+// 在飞机前方渲染护盾
+func (s *sceneMain) renderShield(position sdl.FPoint) {
+	if s.isDead || !s.player.shield.isVisible(s.currentTime()) {
+		return
+	}
+	width := s.player.width * 1.6
+	height := width * s.shieldHeight / s.shieldWidth
+	ds := sdl.FRect{
+		X: position.X + s.player.width/2 - width/2,
+		Y: position.Y - height/2,
+		W: width,
+		H: height,
+	}
+	sdl.RenderTexture(GetInstance().sdlRenderer, s.shieldTexture, nil, &ds)
+}
+
+// 时间减速时给画面加上蓝色
+func (s *sceneMain) renderTimeSlow() {
+	if !s.timeSlow.isVisible(s.currentTime()) {
+		return
+	}
+	rect := sdl.FRect{
+		X: 0,
+		Y: 0,
+		W: float32(GetInstance().windowWidth),
+		H: float32(GetInstance().windowHeight),
+	}
+	GetInstance().renderFillRect(rect, sdl.Color{R: 40, G: 80, B: 200, A: 50})
+}
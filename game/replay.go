@@ -0,0 +1,250 @@
+package game
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// 回放文件格式（小端）：
+// 魔数"SSRP" | 版本uint16 | 逻辑帧率uint32 | 游戏区域宽int32 | 游戏区域高int32 | 随机种子int64 | 最终得分uint32 | 逻辑帧数uint64 | 游程数uvarint
+// 之后每个游程为 持续逻辑帧数uvarint | 水平方向int8 | 垂直方向int8 | 按键位uint8
+const (
+	replayMagic   = "SSRP"
+	replayVersion = uint16(1)
+	// 回放文件目录
+	replayDir = "assets/replay"
+)
+
+// 连续相同输入的一段
+type replayRun struct {
+	ticks uint64
+	state packedInput
+}
+
+// 回放数据
+type replay struct {
+	// 逻辑帧率
+	tickRate uint32
+	// 游戏区域的逻辑尺寸
+	width  int32
+	height int32
+	// 随机种子
+	seed int64
+	// 最终得分
+	score uint32
+	// 逻辑帧数
+	ticks uint64
+	// 输入游程
+	runs []replayRun
+}
+
+// 录制一个逻辑帧的输入
+func (r *replay) record(state inputState) {
+	packed := state.pack()
+	r.ticks++
+	if n := len(r.runs); n > 0 && r.runs[n-1].state == packed {
+		r.runs[n-1].ticks++
+		return
+	}
+	r.runs = append(r.runs, replayRun{ticks: 1, state: packed})
+}
+
+// 编码回放数据
+func (r *replay) encode() []byte {
+	buf := make([]byte, 0, 32+len(r.runs)*5)
+	buf = append(buf, replayMagic...)
+	buf = binary.LittleEndian.AppendUint16(buf, replayVersion)
+	buf = binary.LittleEndian.AppendUint32(buf, r.tickRate)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(r.width))
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(r.height))
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(r.seed))
+	buf = binary.LittleEndian.AppendUint32(buf, r.score)
+	buf = binary.LittleEndian.AppendUint64(buf, r.ticks)
+	buf = binary.AppendUvarint(buf, uint64(len(r.runs)))
+	for _, run := range r.runs {
+		buf = binary.AppendUvarint(buf, run.ticks)
+		buf = append(buf, byte(run.state.moveX), byte(run.state.moveY), run.state.buttons)
+	}
+	return buf
+}
+
+// 保存回放文件
+func (r *replay) save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create replay dir, %v, %v", path, err)
+	}
+	if err := os.WriteFile(path, r.encode(), 0644); err != nil {
+		return fmt.Errorf("failed to write replay, %v, %v", path, err)
+	}
+	return nil
+}
+
+// 解码回放数据
+func decodeReplay(data []byte) (*replay, error) {
+	reader := bytes.NewReader(data)
+	magic := make([]byte, len(replayMagic))
+	if _, err := reader.Read(magic); err != nil || string(magic) != replayMagic {
+		return nil, fmt.Errorf("invalid replay magic")
+	}
+	var header struct {
+		Version  uint16
+		TickRate uint32
+		Width    int32
+		Height   int32
+		Seed     int64
+		Score    uint32
+		Ticks    uint64
+	}
+	if err := binary.Read(reader, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("invalid replay header, %v", err)
+	}
+	if header.Version != replayVersion {
+		return nil, fmt.Errorf("unsupported replay version, %v", header.Version)
+	}
+	if header.TickRate == 0 {
+		return nil, fmt.Errorf("invalid replay tick rate")
+	}
+	if header.Width <= 0 || header.Height <= 0 {
+		return nil, fmt.Errorf("invalid replay playfield size, %vx%v", header.Width, header.Height)
+	}
+	count, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return nil, fmt.Errorf("invalid replay run count, %v", err)
+	}
+
+	r := &replay{
+		tickRate: header.TickRate,
+		width:    header.Width,
+		height:   header.Height,
+		seed:     header.Seed,
+		score:    header.Score,
+		ticks:    header.Ticks,
+		runs:     make([]replayRun, 0, min(count, uint64(len(data)))),
+	}
+	total := uint64(0)
+	for i := uint64(0); i < count; i++ {
+		ticks, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return nil, fmt.Errorf("invalid replay run %v, %v", i, err)
+		}
+		var raw [3]byte
+		if _, err := io.ReadFull(reader, raw[:]); err != nil {
+			return nil, fmt.Errorf("invalid replay run %v, %v", i, err)
+		}
+		state := packedInput{moveX: int8(raw[0]), moveY: int8(raw[1]), buttons: raw[2]}
+		r.runs = append(r.runs, replayRun{ticks: ticks, state: state})
+		total += ticks
+	}
+	if total != r.ticks {
+		return nil, fmt.Errorf("replay tick count mismatch, header %v, runs %v", r.ticks, total)
+	}
+	return r, nil
+}
+
+// 载入回放文件
+func loadReplay(path string) (*replay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replay, %v, %v", path, err)
+	}
+	r, err := decodeReplay(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode replay, %v, %v", path, err)
+	}
+	return r, nil
+}
+
+// 新的回放文件路径
+func newReplayPath() string {
+	return filepath.Join(GetInstance().assets.dataPath(replayDir), time.Now().Format("20060102-150405")+".rep")
+}
+
+// 回放输入源，按逻辑帧读取录制的输入
+type replayInput struct {
+	replay *replay
+	// 当前游程
+	index int
+	// 当前游程已经执行的逻辑帧数
+	elapsed uint64
+}
+
+var _ inputSource = (*replayInput)(nil)
+
+func (r *replayInput) poll() inputState {
+	runs := r.replay.runs
+	for r.index < len(runs) && r.elapsed >= runs[r.index].ticks {
+		r.index++
+		r.elapsed = 0
+	}
+	if r.index >= len(runs) {
+		return inputState{}
+	}
+	r.elapsed++
+	return runs[r.index].state.unpack()
+}
+
+// 回放场景，由回放文件驱动玩家
+type sceneReplay struct {
+	sceneMain
+	// 回放文件路径
+	path string
+	// 进入回放前的固定步长设置，退出回放时恢复
+	lastFixedStep bool
+	lastTickRate  uint32
+}
+
+var _ loadableScene = (*sceneReplay)(nil)
+
+func (s *sceneReplay) init() error {
+	r, err := loadReplay(s.path)
+	if err != nil {
+		return err
+	}
+	// 回放必须以录制时的逻辑帧率运行
+	g := GetInstance()
+	s.lastFixedStep = g.fixedStep
+	s.lastTickRate = g.tickRate
+	g.SetFixedStep(true, r.tickRate)
+	s.replay = r
+	if err := s.sceneMain.init(); err != nil {
+		g.SetFixedStep(s.lastFixedStep, s.lastTickRate)
+		return err
+	}
+	return nil
+}
+
+func (s *sceneReplay) clean() {
+	s.sceneMain.clean()
+	GetInstance().SetFixedStep(s.lastFixedStep, s.lastTickRate)
+}
+
+func (s *sceneReplay) render() {
+	s.sceneMain.render()
+	GetInstance().renderTextCentered("回放中", 0.95, false)
+}
+
+// 无头模式重新模拟回放，检查最终得分和录制时一致
+func (g *Game) VerifyReplay(path string) (result HeadlessResult, err error) {
+	r, err := loadReplay(path)
+	if err != nil {
+		return result, err
+	}
+	// 以录制时的逻辑帧率模拟，结束后恢复
+	defer g.SetFixedStep(g.fixedStep, g.tickRate)
+	g.SetFixedStep(true, r.tickRate)
+
+	result, err = g.runHeadless(&sceneMain{replay: r}, r.ticks)
+	if err != nil {
+		return result, err
+	}
+	if result.Seed != r.seed || result.Score != r.score || result.Ticks != r.ticks {
+		return result, fmt.Errorf("replay verify failed, %v, expect score %v at tick %v, got score %v at tick %v",
+			path, r.score, r.ticks, result.Score, result.Ticks)
+	}
+	return result, nil
+}
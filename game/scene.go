@@ -6,9 +6,93 @@ import (
 
 // 场景接口
 type iscene interface {
-	init()
+	// 初始化场景，失败时场景自己释放已经取得的资源
+	init() error
 	update(deltaTime float32)
 	render()
 	clean()
 	handleEvent(event sdl.Event)
 }
+
+// 需要预载资源的场景
+type loadableScene interface {
+	iscene
+	// 场景使用的资源清单，在init之前调用
+	manifest() (assetManifest, error)
+}
+
+// 需要预载资源的场景先进入载入场景，在后台解析资源清单并载入资源，完成后再以效果t切换到该场景
+func (g *Game) loadingScene(scene iscene, t transition) iscene {
+	next, ok := scene.(loadableScene)
+	if !ok {
+		return scene
+	}
+	return &sceneLoading{next: next, transition: t}
+}
+
+// 压入场景，下面的场景继续渲染，但不再更新和接收事件，初始化失败时改为显示错误页面
+func (g *Game) pushScene(scene iscene) {
+	g.scenes = append(g.scenes, scene)
+	if err := scene.init(); err != nil {
+		failed := &sceneLoading{err: err}
+		g.scenes[len(g.scenes)-1] = failed
+		failed.init()
+	}
+}
+
+// 弹出最上面的场景
+func (g *Game) popScene() {
+	n := len(g.scenes)
+	if n == 0 {
+		return
+	}
+	top := g.scenes[n-1]
+	g.scenes[n-1] = nil
+	g.scenes = g.scenes[:n-1]
+	top.clean()
+}
+
+// 用新场景替换最上面的场景
+func (g *Game) replaceScene(scene iscene, t transition) {
+	g.swapScene(g.loadingScene(scene, t), t)
+}
+
+// 用场景替换最上面的场景，不经过载入场景，t为transitionNone时不打断正在进行的切换
+func (g *Game) swapScene(scene iscene, t transition) {
+	if t.kind != transitionNone {
+		g.beginTransition(t)
+	}
+	g.popScene()
+	g.pushScene(scene)
+	g.assets.sceneSwitched()
+}
+
+// 清空场景栈并切换到新场景
+func (g *Game) changeScene(scene iscene, t transition) {
+	g.beginTransition(t)
+	g.clearScenes()
+	g.pushScene(g.loadingScene(scene, t))
+	g.assets.sceneSwitched()
+}
+
+// 从上到下清理并移除所有场景
+func (g *Game) clearScenes() {
+	for len(g.scenes) > 0 {
+		g.popScene()
+	}
+}
+
+// 最上面的场景，场景栈为空时返回nil
+func (g *Game) topScene() iscene {
+	if len(g.scenes) == 0 {
+		return nil
+	}
+	return g.scenes[len(g.scenes)-1]
+}
+
+// 从下到上渲染场景栈中的所有场景
+func (g *Game) renderScenes() {
+	for _, scene := range g.scenes {
+		scene.render()
+	}
+}
@@ -16,18 +16,39 @@ type sceneEnd struct {
 	name string
 	// 闪烁的光标计时器
 	blinkTimer float32
+	// 排行榜菜单
+	menu menu
 }
 
-var _ iscene = (*sceneEnd)(nil)
+// 排行榜菜单选项
+const (
+	endMenuRestart = iota
+	endMenuTitle
+)
+
+// 结束场景使用的资源
+var sceneEndAssets = assetManifest{music: []string{introMusicPath}}
+
+var _ loadableScene = (*sceneEnd)(nil)
 
-func (s *sceneEnd) init() {
+func (s *sceneEnd) manifest() (assetManifest, error) {
+	return sceneEndAssets, nil
+}
+
+func (s *sceneEnd) init() error {
 	s.isTyping = true
 	s.blinkTimer = 1.0
+	s.menu = menu{items: []string{"重新开始", "返回标题"}}
 
 	// 载入背景音乐
-	bgm, err := newOggPlayer("assets/music/06_Battle_in_Space_Intro.ogg")
+	assets := GetInstance().assets
+	if err := assets.preload(&sceneEndAssets); err != nil {
+		return err
+	}
+	bgm, err := assets.newMusicPlayer(introMusicPath)
 	if err != nil {
-		panic(err)
+		assets.release(&sceneEndAssets)
+		return err
 	}
 	s.bgm = bgm
 	s.bgm.SetLoop(true)
@@ -39,6 +60,7 @@ func (s *sceneEnd) init() {
 	if !sdl.TextInputActive(GetInstance().sdlWindow) {
 		fmt.Printf("failed to start text input: %s\n", sdl.GetError())
 	}
+	return nil
 }
 
 func (s *sceneEnd) update(deltaTime float32) {
@@ -61,6 +83,7 @@ func (s *sceneEnd) clean() {
 		s.bgm.Close()
 		s.bgm = nil
 	}
+	GetInstance().assets.release(&sceneEndAssets)
 }
 
 func (s *sceneEnd) handleEvent(event sdl.Event) {
@@ -69,31 +92,33 @@ func (s *sceneEnd) handleEvent(event sdl.Event) {
 			ti := event.Text()
 			s.name += ti.Text()
 		}
-		if event.Type() == sdl.EventKeyDown {
-			if event.Key().Scancode == sdl.ScancodeReturn {
-				s.isTyping = false
-				sdl.StopTextInput(GetInstance().sdlWindow)
-				if s.name == "" {
-					s.name = "无名氏"
-				}
-				GetInstance().insertLeaderBoard(GetInstance().finalScore, s.name)
+		if GetInstance().bindings.isPressed(event, actionTextSubmit) {
+			s.isTyping = false
+			sdl.StopTextInput(GetInstance().sdlWindow)
+			if s.name == "" {
+				s.name = "无名氏"
 			}
-			if event.Key().Scancode == sdl.ScancodeBackspace {
-				if len(s.name) == 0 {
-					return
-				}
-				runes := []rune(s.name)
-				if len(runes) > 0 {
-					runes = runes[:len(runes)-1]
-					s.name = string(runes)
-				}
+			GetInstance().insertLeaderBoard(GetInstance().finalScore, s.name)
+		}
+		if GetInstance().bindings.isPressed(event, actionTextDelete) {
+			if len(s.name) == 0 {
+				return
+			}
+			runes := []rune(s.name)
+			if len(runes) > 0 {
+				runes = runes[:len(runes)-1]
+				s.name = string(runes)
 			}
 		}
 	} else {
-		if event.Type() == sdl.EventKeyDown {
-			if event.Key().Scancode == sdl.ScancodeJ {
-				GetInstance().changeScene(&sceneMain{})
-			}
+		if !s.menu.handleEvent(event) {
+			return
+		}
+		switch s.menu.selected {
+		case endMenuRestart:
+			GetInstance().replaceScene(&sceneMain{}, wipeTransition)
+		case endMenuTitle:
+			GetInstance().replaceScene(&sceneTitle{}, fadeTransition)
 		}
 	}
 }
@@ -101,9 +126,14 @@ func (s *sceneEnd) handleEvent(event sdl.Event) {
 func (s *sceneEnd) renderPhase1() {
 	score := GetInstance().finalScore
 	scoreText := "你的得分是：" + strconv.FormatUint(uint64(score), 10)
+	seedText := "随机种子：" + strconv.FormatInt(GetInstance().finalSeed, 10)
 	gameOver := "Game Over"
-	instrutionText := "请输入你的名字，按回车键确认："
+	if GetInstance().finalCleared {
+		gameOver = "Victory"
+	}
+	instrutionText := "请输入你的名字，按 " + GetInstance().bindings.keyName(actionTextSubmit) + " 键确认："
 	GetInstance().renderTextCentered(scoreText, 0.1, false)
+	GetInstance().renderTextCentered(seedText, 0.2, false)
 	GetInstance().renderTextCentered(gameOver, 0.4, true)
 	GetInstance().renderTextCentered(instrutionText, 0.6, false)
 
@@ -130,7 +160,5 @@ func (s *sceneEnd) renderPhase2() {
 			i++
 		}
 	}
-	if s.blinkTimer < 0.5 {
-		GetInstance().renderTextCentered("按 J 键重新开始游戏", 0.85, false)
-	}
+	s.menu.render(0.8, 0.07)
 }
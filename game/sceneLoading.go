@@ -0,0 +1,135 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/SunshineZzzz/purego-sdl3/sdl"
+)
+
+// 载入进度条的尺寸，相对窗口宽度和高度的比例
+const (
+	loadingBarWidth  = 0.6
+	loadingBarHeight = 0.02
+)
+
+// 载入场景，后台解析下一个场景的资源清单并载入资源，显示进度，全部载入后切换到下一个场景
+type sceneLoading struct {
+	// 载入完成后切换到的场景
+	next loadableScene
+	// 切换到下一个场景的效果
+	transition transition
+	// 后台解析资源清单的结果
+	resolved chan manifestResult
+	// 下一个场景的资源清单
+	manifest assetManifest
+	// 正在进行的载入，资源清单解析完成后开始
+	load *assetLoad
+	// 载入失败的原因
+	err error
+}
+
+// 资源清单的解析结果
+type manifestResult struct {
+	// 资源清单
+	manifest assetManifest
+	// 解析失败的原因
+	err error
+}
+
+var _ iscene = (*sceneLoading)(nil)
+
+func (s *sceneLoading) init() error {
+	if s.err != nil {
+		fmt.Println(s.err)
+		return nil
+	}
+	// 资源清单可能要读取和解析定义文件，在后台协程进行，结果在update中取得
+	s.resolved = make(chan manifestResult, 1)
+	next := s.next
+	go func() {
+		manifest, err := next.manifest()
+		s.resolved <- manifestResult{manifest: manifest, err: err}
+	}()
+	return nil
+}
+
+func (s *sceneLoading) update(float32) {
+	if s.err != nil {
+		return
+	}
+	if s.load == nil && !s.resolve() {
+		return
+	}
+	if !s.load.done() {
+		return
+	}
+	if err := s.load.err(); err != nil {
+		s.err = err
+		fmt.Println(err)
+		return
+	}
+	// 还在切换到载入场景的过程中就已经载入完成时直接替换，继续播放当前的切换效果
+	g := GetInstance()
+	if g.isTransitioning() {
+		g.swapScene(s.next, transition{})
+		return
+	}
+	g.swapScene(s.next, s.transition)
+}
+
+// 取得后台解析的资源清单并开始载入资源，清单还没有解析完成或者解析失败时返回false
+func (s *sceneLoading) resolve() bool {
+	select {
+	case result := <-s.resolved:
+		if result.err != nil {
+			s.err = result.err
+			fmt.Println(s.err)
+			return false
+		}
+		s.manifest = result.manifest
+		s.load = GetInstance().assets.load(&s.manifest)
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *sceneLoading) render() {
+	g := GetInstance()
+	if s.err != nil {
+		g.renderTextCentered("资源载入失败", 0.4, true)
+		g.renderTextCentered("按 "+g.bindings.keyName(actionConfirm)+" 键退出", 0.6, false)
+		return
+	}
+	progress := float32(0)
+	if s.load != nil {
+		progress = s.load.progress()
+	}
+	g.renderTextCentered("载入中 "+strconv.Itoa(int(progress*100))+"%", 0.45, false)
+
+	width := float32(g.windowWidth)
+	height := float32(g.windowHeight)
+	bar := sdl.FRect{
+		X: width * (1 - loadingBarWidth) / 2,
+		Y: height * 0.55,
+		W: width * loadingBarWidth,
+		H: height * loadingBarHeight,
+	}
+	g.renderFillRect(bar, sdl.Color{R: 60, G: 60, B: 60, A: 255})
+	bar.W *= progress
+	g.renderFillRect(bar, sdl.Color{R: 255, G: 255, B: 255, A: 255})
+}
+
+func (s *sceneLoading) clean() {
+	if s.load != nil {
+		GetInstance().assets.release(&s.manifest)
+		s.load = nil
+	}
+}
+
+func (s *sceneLoading) handleEvent(event sdl.Event) {
+	if s.err != nil && GetInstance().bindings.isPressed(event, actionConfirm) {
+		GetInstance().isRunning = false
+	}
+}
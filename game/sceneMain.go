@@ -1,22 +1,42 @@
 package game
 
 import (
-	"container/list"
 	"fmt"
 	"math"
 	"math/rand"
 	"strconv"
-	"time"
 
-	"github.com/SunshineZzzz/purego-sdl3/img"
 	"github.com/SunshineZzzz/purego-sdl3/sdl"
 	"github.com/SunshineZzzz/purego-sdl3/ttf"
 )
 
 // 标题场景
 type sceneMain struct {
+	// 随机种子
+	seed int64
 	// 随机数生成器
 	rand *rand.Rand
+	// 输入源
+	input inputSource
+	// 回放数据，不为空时由回放驱动输入
+	replay *replay
+	// 回放录制器
+	recorder *replay
+	// 游戏区域的逻辑尺寸，模拟只使用该尺寸，不受窗口大小影响，回放时使用录制时的尺寸
+	fieldWidth  float32
+	fieldHeight float32
+	// 当前逻辑帧
+	tick uint64
+	// 模拟时钟，纳秒，只随逻辑更新推进
+	clock uint64
+	// 敌人时钟，纳秒，时间减速时推进得慢
+	enemyClock uint64
+	// 时间减速效果
+	timeSlow powerUp
+	// 时间减速时的时间缩放
+	timeScale float32
+	// 死亡时的逻辑帧
+	deathTick uint64
 	// 游戏结束定时器
 	timerEnd float32
 	// 分数
@@ -29,198 +49,501 @@ type sceneMain struct {
 	scoreFont *ttf.Font
 	// 音效map
 	sounds map[string]*wavPlayer
+	// 本场景预载的资源
+	assets assetManifest
 	// 玩家
 	player player
 	// 是否死亡
 	isDead bool
-	// 玩家子弹模板
-	projectilePlayerTemplate projectilePlayer
-	// 玩家子弹列表
-	projectilesPlayer *list.List
-	// 敌人模板
-	enemyTemplate enemy
-	// 敌人列表
-	enemies *list.List
-	// 敌人子弹模板
-	projectileEnemyTemplate projectileEnemy
-	// 敌人子弹列表
-	projectilesEnemy *list.List
-	// 爆炸模板
-	explosionTemplate explosion
-	// 爆炸列表
-	explosions *list.List
-	// 声明物品模板
-	itemLifeTemplate item
-	// 物品列表
-	items *list.List
+	// 武器
+	weapons []*weapon
+	// 炸弹参数
+	bomb bombDef
+	// 炸弹图标纹理
+	bombIcon *sdl.Texture
+	// 上次使用炸弹的时间，用于闪光
+	bombTime uint64
+	// 实体世界，保存玩家子弹、敌人、敌人子弹、爆炸和物品
+	world *world
+	// 本逻辑帧的伤害事件，由伤害系统结算
+	damages []damageEvent
+	// 敌人类型
+	enemyTypes []*enemyType
+	// 敌人类型的生成权重
+	enemyWeights []float32
+	// Boss类型
+	bossTypes []*bossType
+	// 当前Boss，没有Boss战时为空
+	boss *boss
+	// 关卡运行器
+	level levelRunner
+	// 是否通过所有关卡
+	isCleared bool
+	// 敌人子弹预制体
+	bulletPrefab prefab
+	// 弹幕
+	patterns map[string]*bulletPattern
+	// 爆炸预制体
+	explosionPrefab prefab
+	// 物品种类
+	itemKinds []itemKind
+	// 物品的掉落权重
+	itemWeights []float32
+	// 敌人被击毁时掉落物品的概率
+	dropChance float32
+	// 护盾纹理
+	shieldTexture *sdl.Texture
+	// 护盾纹理宽度
+	shieldWidth float32
+	// 护盾纹理高度
+	shieldHeight float32
+	// 可以被玩家子弹击中的实体的碰撞网格
+	targetGrid *spatialGrid[entity]
+	// 伤害玩家的实体的碰撞网格
+	hostileGrid *spatialGrid[entity]
+	// 物品的碰撞网格
+	pickupGrid *spatialGrid[entity]
+	// 碰撞网格的查询结果，重复使用避免分配
+	nearby []entity
+	// 实体定义，manifest和init共用，只解析一次
+	defs *entityDefs
 }
 
-var _ iscene = (*sceneMain)(nil)
+var _ loadableScene = (*sceneMain)(nil)
 
-func (s *sceneMain) init() {
-	s.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+const (
+	// 背景音乐
+	mainMusicPath = "assets/music/03_Racing_Through_Asteroids_Loop.ogg"
+	// 生命值图标
+	uiHealthPath = "assets/image/Health UI Black.png"
+	// 分数字体
+	scoreFontPath = "assets/font/VonwaonBitmap-12px.ttf"
+	// 分数字体字号
+	scoreFontSize = 24.0
+)
+
+// 主场景的音效，名字和路径
+var sceneMainSounds = []struct {
+	name string
+	path string
+}{
+	{"player_shoot", "assets/sound/laser_shoot4.wav"},
+	{"enemy_shoot", "assets/sound/xs_laser.wav"},
+	{"player_explode", "assets/sound/explosion1.wav"},
+	{"enemy_explode", "assets/sound/explosion3.wav"},
+	{"hit", "assets/sound/eff11.wav"},
+	{"get_item", "assets/sound/eff5.wav"},
+}
+
+func (s *sceneMain) manifest() (assetManifest, error) {
+	defs, err := s.loadDefs()
+	if err != nil {
+		return assetManifest{}, err
+	}
+	return sceneMainManifest(defs), nil
+}
+
+// 载入实体定义，第一次调用时解析，之后返回同一份定义
+func (s *sceneMain) loadDefs() (*entityDefs, error) {
+	if s.defs == nil {
+		defs, err := loadEntityDefs(entityDefsPath)
+		if err != nil {
+			return nil, err
+		}
+		s.defs = defs
+	}
+	return s.defs, nil
+}
+
+// 主场景的资源清单，包括实体定义中的所有纹理
+func sceneMainManifest(defs *entityDefs) assetManifest {
+	manifest := assetManifest{
+		textures: append([]string{uiHealthPath}, defs.texturePaths()...),
+		fonts:    []fontKey{{path: scoreFontPath, size: scoreFontSize}},
+		music:    []string{mainMusicPath},
+	}
+	for _, sound := range sceneMainSounds {
+		manifest.sounds = append(manifest.sounds, sound.path)
+	}
+	return manifest
+}
+
+func (s *sceneMain) init() (err error) {
+	if s.replay != nil {
+		s.seed = s.replay.seed
+		s.input = &replayInput{replay: s.replay}
+		s.fieldWidth = float32(s.replay.width)
+		s.fieldHeight = float32(s.replay.height)
+	} else {
+		s.seed = GetInstance().nextSeed()
+		s.fieldWidth = float32(GetInstance().windowWidth)
+		s.fieldHeight = float32(GetInstance().windowHeight)
+	}
+	s.rand = rand.New(rand.NewSource(s.seed))
+	if s.input == nil {
+		s.input = deviceInput{}
+	}
+	// 固定步长下正常游戏时录制回放
+	s.recorder = nil
+	if s.replay == nil && !GetInstance().headless && GetInstance().fixedStep {
+		s.recorder = &replay{
+			tickRate: GetInstance().tickRate,
+			width:    int32(s.fieldWidth),
+			height:   int32(s.fieldHeight),
+			seed:     s.seed,
+		}
+	}
+	s.tick = 0
+	s.clock = 0
+	s.enemyClock = 0
+	s.timeSlow = powerUp{}
+	s.deathTick = 0
 	s.isDead = false
+	s.isCleared = false
 	s.timerEnd = 0.0
 	s.score = 0
-	s.projectilesPlayer = list.New()
-	s.enemies = list.New()
-	s.projectilesEnemy = list.New()
-	s.explosions = list.New()
-	s.items = list.New()
-
-	// 读取并播放背景音乐
-	bgm, err := newOggPlayer("assets/music/03_Racing_Through_Asteroids_Loop.ogg")
+	s.world = newWorld()
+	s.damages = s.damages[:0]
+	s.targetGrid = newSpatialGrid[entity](gridCellSize)
+	s.hostileGrid = newSpatialGrid[entity](gridCellSize)
+	s.pickupGrid = newSpatialGrid[entity](gridCellSize)
+	s.sounds = make(map[string]*wavPlayer)
+
+	// 载入实体定义
+	defs, err := s.loadDefs()
 	if err != nil {
-		panic(err)
+		return err
 	}
-	s.bgm = bgm
-	s.bgm.SetLoop(true)
-	s.bgm.Play()
 
-	// 读取uiHealth纹理
-	s.uiHealth = img.LoadTexture(GetInstance().sdlRenderer, "assets/image/Health UI Black.png")
-	if s.uiHealth == nil {
-		panic("load uiHealth texture error")
+	// 预载本场景使用的资源，预载失败时已经释放
+	assets := GetInstance().assets
+	manifest := sceneMainManifest(defs)
+	if err := assets.preload(&manifest); err != nil {
+		return err
 	}
+	s.assets = manifest
+	// 之后的步骤失败时释放已经取得的资源
+	defer func() {
+		if err != nil {
+			s.clean()
+		}
+	}()
+
+	// 无头模式下不需要音频和UI
+	if !GetInstance().headless {
+		// 播放背景音乐
+		s.bgm, err = assets.newMusicPlayer(mainMusicPath)
+		if err != nil {
+			return err
+		}
+		s.bgm.SetLoop(true)
+		s.bgm.Play()
+
+		s.uiHealth = assets.texture(uiHealthPath).texture
+		s.scoreFont = assets.font(scoreFontPath, scoreFontSize)
 
-	// 载入字体
-	s.scoreFont = ttf.OpenFont("assets/font/VonwaonBitmap-12px.ttf", 24.0)
-	if s.scoreFont == nil {
-		panic("load score font error")
+		// 创建音效播放器
+		for _, sound := range sceneMainSounds {
+			s.sounds[sound.name], err = assets.newSoundPlayer(sound.path)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
-	// 读取音效
-	s.sounds = make(map[string]*wavPlayer)
-	s.sounds["player_shoot"], err = newWavPlayer("assets/sound/laser_shoot4.wav")
+	// 初始化玩家
+	s.player.texture, err = s.assetTexture(defs.Player.Texture, &s.player.width, &s.player.height)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	s.sounds["enemy_shoot"], err = newWavPlayer("assets/sound/xs_laser.wav")
-	if err != nil {
-		panic(err)
+	s.player.speed = defs.Player.Speed
+	s.player.currentHealth = defs.Player.Health
+	s.player.maxHealth = defs.Player.Health
+	s.player.lastShootTime = 0
+	s.player.shield = powerUp{}
+	s.player.invincible = powerUp{}
+	s.player.bombs = defs.Player.Bombs
+	s.player.maxBombs = defs.Player.MaxBombs
+	s.player.bombHeld = false
+	s.player.hitInvincibleMs = defs.Player.HitInvincibleMs
+	s.player.hitFlashMs = defs.Player.HitFlashMs
+	s.player.knockbackSpeed = defs.Player.Knockback
+	s.player.hitTime = 0
+	s.player.knockback = sdl.FPoint{}
+	s.player.width /= defs.Player.ScaleDivisor
+	s.player.height /= defs.Player.ScaleDivisor
+	s.player.focusSpeed = defs.Player.FocusSpeed
+	s.player.focused = false
+	s.player.hitbox = newHitbox(defs.Player.Hitbox, s.player.width, s.player.height)
+	s.player.core = newHitbox(defs.Player.Core, s.player.width, s.player.height)
+	s.player.position.X = s.fieldWidth/2.0 - s.player.width/2.0
+	s.player.position.Y = s.fieldHeight - s.player.height
+	s.player.lastPosition = s.player.position
+
+	// 初始化武器
+	s.weapons = make([]*weapon, 0, len(defs.Weapons))
+	for i := range defs.Weapons {
+		w, err := s.newWeapon(&defs.Weapons[i])
+		if err != nil {
+			return err
+		}
+		s.weapons = append(s.weapons, w)
 	}
-	s.sounds["player_explode"], err = newWavPlayer("assets/sound/explosion1.wav")
+	s.setWeapon(s.weapons[defs.Player.weaponIndex], 0)
+
+	// 初始化炸弹
+	s.bomb = defs.Bomb
+	s.bombTime = 0
+	var iconWidth, iconHeight float32
+	s.bombIcon, err = s.assetTexture(defs.Bomb.Icon, &iconWidth, &iconHeight)
 	if err != nil {
-		panic(err)
-	}
-	s.sounds["enemy_explode"], err = newWavPlayer("assets/sound/explosion3.wav")
+		return err
+	}
+
+	// 初始化弹幕
+	s.patterns = make(map[string]*bulletPattern, len(defs.Patterns))
+	for i := range defs.Patterns {
+		pattern := newBulletPattern(&defs.Patterns[i])
+		s.patterns[pattern.name] = pattern
+	}
+
+	// 初始化敌人类型
+	s.enemyTypes = make([]*enemyType, 0, len(defs.Enemies))
+	s.enemyWeights = make([]float32, 0, len(defs.Enemies))
+	for i := range defs.Enemies {
+		def := &defs.Enemies[i]
+		kind := &enemyType{
+			name:     def.Name,
+			score:    def.Score,
+			weight:   def.Weight,
+			movement: movementKinds[def.Movement.Type],
+			params:   def.Movement,
+			pattern:  s.patterns[def.Pattern],
+		}
+		p := &kind.prefab
+		p.sprite.texture, err = s.assetTexture(def.Texture, &p.width, &p.height)
+		if err != nil {
+			return err
+		}
+		p.sprite.layer = layerEnemy
+		p.width /= def.ScaleDivisor
+		p.height /= def.ScaleDivisor
+		p.team = teamEnemy
+		p.hitbox = newHitbox(def.Hitbox, p.width, p.height)
+		// 撞上玩家造成1点伤害
+		p.damage = 1
+		p.health = def.Health
+		p.speed = def.Speed
+		// 从屏幕上方入场
+		p.lifetime = lifetime{offscreen: true, allowAbove: true}
+		kind.coolDown = def.CoolDownMs
+		s.enemyTypes = append(s.enemyTypes, kind)
+		s.enemyWeights = append(s.enemyWeights, kind.weight)
+	}
+
+	// 初始化Boss类型
+	s.boss = nil
+	s.bossTypes = make([]*bossType, 0, len(defs.Bosses))
+	for i := range defs.Bosses {
+		def := &defs.Bosses[i]
+		kind := &bossType{
+			name:            def.Name,
+			score:           def.Score,
+			bodyDamageScale: def.BodyDamageScale,
+			swayAmplitude:   def.SwayAmplitude,
+			swayFrequency:   def.SwayFrequency,
+			enterY:          def.EnterY,
+			weakPoints:      def.WeakPoints,
+			phases:          def.Phases,
+		}
+		kind.template.texture, err = s.assetTexture(def.Texture, &kind.template.width, &kind.template.height)
+		if err != nil {
+			return err
+		}
+		kind.template.width /= def.ScaleDivisor
+		kind.template.height /= def.ScaleDivisor
+		kind.template.hitbox = newHitbox(def.Hitbox, kind.template.width, kind.template.height)
+		kind.template.speed = def.Speed
+		kind.template.currentHealth = float32(def.Health)
+		kind.template.maxHealth = float32(def.Health)
+		s.bossTypes = append(s.bossTypes, kind)
+	}
+
+	// 初始化敌人子弹预制体
+	p := &s.bulletPrefab
+	p.sprite.texture, err = s.assetTexture(defs.ProjectileEnemy.Texture, &p.width, &p.height)
 	if err != nil {
-		panic(err)
-	}
-	s.sounds["hit"], err = newWavPlayer("assets/sound/eff11.wav")
+		return err
+	}
+	p.sprite.layer = layerEnemyProjectile
+	// 纹理朝下
+	p.sprite.rotate = true
+	p.sprite.angleOffset = -90
+	p.width /= defs.ProjectileEnemy.ScaleDivisor
+	p.height /= defs.ProjectileEnemy.ScaleDivisor
+	p.team = teamEnemy
+	p.hitbox = newHitbox(defs.ProjectileEnemy.Hitbox, p.width, p.height)
+	p.damage = defs.ProjectileEnemy.Damage
+	p.speed = defs.ProjectileEnemy.Speed
+	p.lifetime = lifetime{offscreen: true, margin: projectileMargin}
+
+	// 初始化爆炸预制体，序列帧横向排列，每帧为正方形
+	p = &s.explosionPrefab
+	p.sprite.texture, err = s.assetTexture(defs.Explosion.Texture, &p.width, &p.height)
 	if err != nil {
-		panic(err)
+		return err
+	}
+	p.sprite.layer = layerExplosion
+	p.sprite.frames = p.width / p.height
+	p.sprite.fps = defs.Explosion.Fps
+	p.width = p.height
+	p.team = teamNeutral
+
+	// 初始化物品种类
+	s.dropChance = defs.DropChance
+	s.itemKinds = make([]itemKind, len(defs.Items))
+	s.itemWeights = make([]float32, len(defs.Items))
+	for i := range defs.Items {
+		def := &defs.Items[i]
+		kind := &s.itemKinds[i]
+		p := &kind.prefab
+		p.sprite.texture, err = s.assetTexture(def.Texture, &p.width, &p.height)
+		if err != nil {
+			return err
+		}
+		p.sprite.layer = layerItem
+		p.width /= def.ScaleDivisor
+		p.height /= def.ScaleDivisor
+		p.team = teamNeutral
+		p.hitbox = newHitbox(def.Hitbox, p.width, p.height)
+		p.speed = def.Speed
+		p.lifetime = lifetime{offscreen: true}
+		kind.pickup = pickup{
+			itemType:    itemTypes[def.Type],
+			duration:    def.DurationMs,
+			warn:        def.WarnMs,
+			timeScale:   def.TimeScale,
+			bounceCount: def.BounceCount,
+		}
+		if kind.pickup.itemType == itemTypeWeapon {
+			kind.pickup.weapon = s.weapons[def.weaponIndex]
+		}
+		s.itemWeights[i] = def.Weight
+		if kind.pickup.itemType == itemTypeShield {
+			s.shieldTexture, err = s.assetTexture(def.EffectTexture, &s.shieldWidth, &s.shieldHeight)
+			if err != nil {
+				return err
+			}
+		}
 	}
-	s.sounds["get_item"], err = newWavPlayer("assets/sound/eff5.wav")
+
+	// 载入关卡并从第一关开始
+	levels, err := loadLevelDefs(levelDefsPath, defs)
 	if err != nil {
-		panic(err)
+		return err
 	}
+	s.level.start(levels, s.currentTime())
+	return nil
+}
 
-	// 初始化玩家
-	s.player.texture = img.LoadTexture(GetInstance().sdlRenderer, "assets/image/SpaceShip.png")
-	s.player.speed = 300.0
-	s.player.currentHealth = 3
-	s.player.maxHealth = 3
-	s.player.coolDown = 300
-	s.player.lastShootTime = 0
-	if s.player.texture == nil {
-		panic("load player texture error")
-	}
-	sdl.GetTextureSize(s.player.texture, &s.player.width, &s.player.height)
-	s.player.width /= 5.0
-	s.player.height /= 5.0
-	s.player.position.X = float32(GetInstance().windowWidth)/2.0 - s.player.width/2.0
-	s.player.position.Y = float32(GetInstance().windowHeight) - s.player.height
-
-	// 初始化玩家子弹模板
-	s.projectilePlayerTemplate.texture = img.LoadTexture(GetInstance().sdlRenderer, "assets/image/laser-1.png")
-	if s.projectilePlayerTemplate.texture == nil {
-		panic("load projectile player texture error")
-	}
-	sdl.GetTextureSize(s.projectilePlayerTemplate.texture, &s.projectilePlayerTemplate.width, &s.projectilePlayerTemplate.height)
-	s.projectilePlayerTemplate.width /= 4.0
-	s.projectilePlayerTemplate.height /= 4.0
-	s.projectilePlayerTemplate.speed = 600.0
-	s.projectilePlayerTemplate.damage = 1
-
-	// 初始化敌人模板
-	s.enemyTemplate.texture = img.LoadTexture(GetInstance().sdlRenderer, "assets/image/insect-2.png")
-	if s.enemyTemplate.texture == nil {
-		panic("load enemy texture error")
-	}
-	sdl.GetTextureSize(s.enemyTemplate.texture, &s.enemyTemplate.width, &s.enemyTemplate.height)
-	s.enemyTemplate.width /= 4.0
-	s.enemyTemplate.height /= 4.0
-	s.enemyTemplate.speed = 150.0
-	s.enemyTemplate.currentHealth = 2
-	s.enemyTemplate.coolDown = 2000
-	s.enemyTemplate.lastShootTime = 0
-
-	// 初始化敌人子弹模板
-	s.projectileEnemyTemplate.texture = img.LoadTexture(GetInstance().sdlRenderer, "assets/image/bullet-1.png")
-	if s.projectileEnemyTemplate.texture == nil {
-		panic("load projectile enemy texture error")
-	}
-	sdl.GetTextureSize(s.projectileEnemyTemplate.texture, &s.projectileEnemyTemplate.width, &s.projectileEnemyTemplate.height)
-	s.projectileEnemyTemplate.width /= 2.0
-	s.projectileEnemyTemplate.height /= 2.0
-	s.projectileEnemyTemplate.speed = 400.0
-	s.projectileEnemyTemplate.damage = 1
-
-	// 初始化爆炸模板
-	s.explosionTemplate.texture = img.LoadTexture(GetInstance().sdlRenderer, "assets/effect/explosion.png")
-	if s.explosionTemplate.texture == nil {
-		panic("load explosion texture error")
-	}
-	sdl.GetTextureSize(s.explosionTemplate.texture, &s.explosionTemplate.width, &s.explosionTemplate.height)
-	s.explosionTemplate.totalFrame = s.explosionTemplate.width / s.explosionTemplate.height
-	s.explosionTemplate.width = s.explosionTemplate.height
-	s.explosionTemplate.fps = 10
-
-	// 初始化物品模板
-	s.itemLifeTemplate.texture = img.LoadTexture(GetInstance().sdlRenderer, "assets/image/bonus_life.png")
-	if s.itemLifeTemplate.texture == nil {
-		panic("load item life texture error")
-	}
-	sdl.GetTextureSize(s.itemLifeTemplate.texture, &s.itemLifeTemplate.width, &s.itemLifeTemplate.height)
-	s.itemLifeTemplate.width /= 4.0
-	s.itemLifeTemplate.height /= 4.0
-	s.itemLifeTemplate.speed = 200.0
-	s.itemLifeTemplate.bounceCount = 3
-	s.itemLifeTemplate.itemType = itemTypeLife
+// 预载过的纹理和尺寸，无头模式下纹理为nil
+func (s *sceneMain) assetTexture(path string, width *float32, height *float32) (*sdl.Texture, error) {
+	asset := GetInstance().assets.texture(path)
+	if asset == nil {
+		return nil, fmt.Errorf("texture not preloaded, %v", path)
+	}
+	*width = asset.width
+	*height = asset.height
+	return asset.texture, nil
+}
+
+// 播放音效，无头模式下没有音效
+func (s *sceneMain) playSound(name string) {
+	if sound, ok := s.sounds[name]; ok {
+		sound.Play()
+	}
+}
+
+// 当前模拟时间，毫秒，不受墙上时钟影响
+func (s *sceneMain) currentTime() uint64 {
+	return s.clock / 1000000
 }
 
 func (s *sceneMain) update(deltaTime float32) {
+	s.tick++
+	s.clock += uint64(float64(deltaTime) * 1e9)
+	// 敌人和敌人子弹受时间减速影响
+	enemyDeltaTime := deltaTime * s.enemyTimeScale()
+	s.enemyClock += uint64(float64(enemyDeltaTime) * 1e9)
 	s.keyboardControl(deltaTime)
-	s.updatePlayerProjectiles(deltaTime)
-	s.updateEnemyProjectiles(deltaTime)
-	s.spawEnemy()
-	s.updateEnemies(deltaTime)
+	s.homingSystem(deltaTime)
+	s.steeringSystem(enemyDeltaTime)
+	s.movementSystem(deltaTime, enemyDeltaTime)
+	s.updateItems()
+	s.updateLevel()
+	s.updateEnemies(enemyDeltaTime)
+	s.updateBoss(enemyDeltaTime)
+	s.collisionSystem()
+	s.damageSystem()
 	s.updatePlayer(deltaTime)
-	s.updateExplosions(deltaTime)
-	s.updateItems(deltaTime)
-	if s.isDead {
-		// 3秒后切换到标题场景
+	s.cleanupSystem()
+	if s.level.isFinished() && !s.isDead && !s.isCleared {
+		s.isCleared = true
+		GetInstance().finalScore = s.score
+		GetInstance().finalSeed = s.seed
+		GetInstance().finalCleared = true
+		s.saveReplay()
+	}
+	if s.isDead || s.isCleared {
+		// 3秒后切换到结束场景
 		s.changeSceneDelayed(deltaTime, 3)
 	}
 }
 
 func (s *sceneMain) render() {
 	// 渲染玩家子弹
-	s.renderPlayerProjectiles()
+	s.renderSystem(layerPlayerProjectile)
 	// 渲染敌机子弹
-	s.renderEnemyProjectiles()
-	// 渲染玩家
-	if !s.isDead {
-		ds := sdl.FRect{X: s.player.position.X, Y: s.player.position.Y, W: s.player.width, H: s.player.height}
+	s.renderSystem(layerEnemyProjectile)
+	// 渲染玩家，无敌时闪烁
+	if !s.isDead && (!s.player.invincible.isActive(s.currentTime()) || s.player.invincible.isVisible(s.currentTime())) {
+		position := GetInstance().interpolate(s.player.lastPosition, s.player.position)
+		ds := sdl.FRect{X: position.X, Y: position.Y, W: s.player.width, H: s.player.height}
+		// 刚受伤时闪红
+		flash := s.isHitFlashing()
+		if flash {
+			sdl.SetTextureColorMod(s.player.texture, 255, 60, 60)
+		}
 		sdl.RenderTexture(GetInstance().sdlRenderer, s.player.texture, nil, &ds)
+		if flash {
+			sdl.SetTextureColorMod(s.player.texture, 255, 255, 255)
+		}
+		s.renderShield(position)
+		// 低速模式下显示判定点
+		if s.player.focused {
+			s.player.core.render(position, sdl.Color{R: 255, G: 255, B: 255, A: 255})
+		}
 	}
 	// 渲染敌人
-	s.renderEnemies()
+	s.renderSystem(layerEnemy)
+	// 渲染Boss
+	s.renderBoss()
 	// 渲染物品
-	s.renderItems()
+	s.renderSystem(layerItem)
 	// 渲染爆炸效果
-	s.renderExplosions()
+	s.renderSystem(layerExplosion)
+	// 渲染时间减速效果
+	s.renderTimeSlow()
+	// 渲染炸弹闪光
+	s.renderBombFlash()
+	// 渲染受伤闪红
+	s.renderHitFlash()
+	// 渲染碰撞盒
+	if GetInstance().showHitboxes {
+		s.renderHitboxes()
+	}
+	// 渲染关卡提示
+	s.renderLevel()
 	// 渲染UI
 	s.renderUI()
 }
@@ -230,278 +553,182 @@ func (s *sceneMain) clean() {
 		s.bgm.Close()
 		s.bgm = nil
 	}
-	if s.uiHealth != nil {
-		sdl.DestroyTexture(s.uiHealth)
-		s.uiHealth = nil
-	}
-	if s.scoreFont != nil {
-		ttf.CloseFont(s.scoreFont)
-		s.scoreFont = nil
-	}
 	for _, sound := range s.sounds {
 		sound.Close()
 	}
 	s.sounds = nil
-	if s.player.texture != nil {
-		sdl.DestroyTexture(s.player.texture)
-		s.player.texture = nil
-	}
-	if s.projectilePlayerTemplate.texture != nil {
-		sdl.DestroyTexture(s.projectilePlayerTemplate.texture)
-		s.projectilePlayerTemplate.texture = nil
-	}
-	if s.projectileEnemyTemplate.texture != nil {
-		sdl.DestroyTexture(s.projectileEnemyTemplate.texture)
-		s.projectileEnemyTemplate.texture = nil
-	}
-	if s.explosionTemplate.texture != nil {
-		sdl.DestroyTexture(s.explosionTemplate.texture)
-		s.explosionTemplate.texture = nil
-	}
-	if s.itemLifeTemplate.texture != nil {
-		sdl.DestroyTexture(s.itemLifeTemplate.texture)
-		s.itemLifeTemplate.texture = nil
-	}
-	s.projectilesPlayer = nil
-	s.enemies = nil
-	s.projectilesEnemy = nil
-	s.explosions = nil
-	s.items = nil
+	// 纹理和字体由资源管理器释放
+	GetInstance().assets.release(&s.assets)
+	s.assets = assetManifest{}
+	s.uiHealth = nil
+	s.scoreFont = nil
+	s.player.texture = nil
+	s.weapons = nil
+	s.bombIcon = nil
+	s.enemyTypes = nil
+	s.enemyWeights = nil
+	s.bossTypes = nil
+	s.boss = nil
+	s.bulletPrefab = prefab{}
+	s.explosionPrefab = prefab{}
+	s.itemKinds = nil
+	s.itemWeights = nil
+	s.shieldTexture = nil
+	s.world = nil
+	s.damages = nil
+	s.patterns = nil
+	s.targetGrid = nil
+	s.hostileGrid = nil
+	s.pickupGrid = nil
+	s.nearby = nil
 }
 
 func (s *sceneMain) handleEvent(event sdl.Event) {
-	if event.Type() == sdl.EventKeyDown {
-		if event.Key().Scancode == sdl.ScancodeEscape {
-			GetInstance().changeScene(&sceneTitle{})
-		}
+	// 窗口失去焦点时自动暂停
+	if event.Type() == sdl.EventWindowFocusLost {
+		s.pause()
+		return
+	}
+	if GetInstance().bindings.isPressed(event, actionPause) {
+		s.pause()
 	}
 }
 
 func (s *sceneMain) keyboardControl(deltaTime float32) {
+	s.player.lastPosition = s.player.position
 	if s.isDead {
 		return
 	}
 
-	// 获取键盘状态
-	keyboardState := sdl.GetKeyboardState()
-	if keyboardState[sdl.ScancodeW] {
-		s.player.position.Y -= deltaTime * s.player.speed
+	// 获取输入状态
+	input := s.input.poll()
+	if s.recorder != nil {
+		s.recorder.record(input)
 	}
-	if keyboardState[sdl.ScancodeS] {
-		s.player.position.Y += deltaTime * s.player.speed
-	}
-	if keyboardState[sdl.ScancodeA] {
-		s.player.position.X -= deltaTime * s.player.speed
-	}
-	if keyboardState[sdl.ScancodeD] {
-		s.player.position.X += deltaTime * s.player.speed
+	// 低速模式下移动变慢，便于在弹幕中穿梭
+	s.player.focused = input.focus
+	speed := s.player.speed
+	if s.player.focused {
+		speed = s.player.focusSpeed
 	}
+	s.player.position.X += input.moveX * deltaTime * speed
+	s.player.position.Y += input.moveY * deltaTime * speed
+	s.updateKnockback(deltaTime)
 
 	// 限制飞机的移动范围
 	if s.player.position.X < 0.0 {
 		s.player.position.X = 0.0
 	}
-	if s.player.position.X > float32(GetInstance().windowWidth)-s.player.width {
-		s.player.position.X = float32(GetInstance().windowWidth) - s.player.width
+	if s.player.position.X > s.fieldWidth-s.player.width {
+		s.player.position.X = s.fieldWidth - s.player.width
 	}
 	if s.player.position.Y < 0.0 {
 		s.player.position.Y = 0.0
 	}
-	if s.player.position.Y > float32(GetInstance().windowHeight)-s.player.height {
-		s.player.position.Y = float32(GetInstance().windowHeight) - s.player.height
+	if s.player.position.Y > s.fieldHeight-s.player.height {
+		s.player.position.Y = s.fieldHeight - s.player.height
 	}
 
 	// 控制子弹发射
-	if keyboardState[sdl.ScancodeSpace] {
-		currentTime := sdl.GetTicks()
+	if input.fire {
+		currentTime := s.currentTime()
 		if currentTime-s.player.lastShootTime > s.player.coolDown {
 			s.shootPlayer()
 			s.player.lastShootTime = currentTime
 		}
 	}
-}
 
-func (s *sceneMain) shootPlayer() {
-	// 在这里实现发射子弹的逻辑
-	projectile := s.projectilePlayerTemplate
-	projectile.position.X = s.player.position.X + s.player.width/2 - projectile.width/2
-	projectile.position.Y = s.player.position.Y
-	s.projectilesPlayer.PushBack(&projectile)
-	s.sounds["player_shoot"].Play()
-}
-
-func (s *sceneMain) renderPlayerProjectiles() {
-	for e := s.projectilesPlayer.Front(); e != nil; e = e.Next() {
-		projectile := e.Value.(*projectilePlayer)
-		ds := sdl.FRect{X: projectile.position.X, Y: projectile.position.Y, W: projectile.width, H: projectile.height}
-		sdl.RenderTexture(GetInstance().sdlRenderer, projectile.texture, nil, &ds)
+	// 按下炸弹键时使用炸弹
+	if input.bomb && !s.player.bombHeld {
+		s.useBomb()
 	}
+	s.player.bombHeld = input.bomb
 }
 
-func (s *sceneMain) renderEnemyProjectiles() {
-	for e := s.projectilesEnemy.Front(); e != nil; e = e.Next() {
-		projectile := e.Value.(*projectileEnemy)
-		ds := sdl.FRect{X: projectile.position.X, Y: projectile.position.Y, W: projectile.width, H: projectile.height}
-		var angle float64 = math.Atan2(float64(projectile.direction.Y), float64(projectile.direction.X))*180/math.Pi - 90.0
-		sdl.RenderTextureRotated(GetInstance().sdlRenderer, projectile.texture, nil, &ds, angle, nil, sdl.FlipNone)
-	}
-}
-
-func (s *sceneMain) updatePlayerProjectiles(deltaTime float32) {
-	// 子弹超出屏幕外边界的距离
-	margin := float32(32.0)
-	for e := s.projectilesPlayer.Front(); e != nil; {
-		next := e.Next()
-
-		projectile := e.Value.(*projectilePlayer)
-		projectile.position.Y -= projectile.speed * deltaTime
-		// 检查子弹是否超出屏幕
-		if projectile.position.Y+margin < 0 {
-			s.projectilesPlayer.Remove(e)
+// 在指定位置加入一个敌人，属于当前波次
+func (s *sceneMain) addEnemy(kind *enemyType, position sdl.FPoint) entity {
+	w := s.world
+	e := s.spawn(&kind.prefab, position)
+	ai := w.enemyAIs.add(w, e)
+	*ai = enemyAI{
+		kind:     kind,
+		origin:   position,
+		wave:     s.level.waveID,
+		emitter:  emitter{pattern: kind.pattern},
+		coolDown: kind.coolDown,
+	}
+	if kind.movement == movementStrafe {
+		// 朝屏幕中间方向开始扫射
+		if position.X+kind.prefab.width/2 < s.fieldWidth/2 {
+			ai.direction.X = 1
 		} else {
-			for e := s.enemies.Front(); e != nil; e = e.Next() {
-				enemy := e.Value.(*enemy)
-				enemyRect := sdl.FRect{
-					X: enemy.position.X,
-					Y: enemy.position.Y,
-					W: enemy.width,
-					H: enemy.height,
-				}
-				projectileRect := sdl.FRect{
-					X: projectile.position.X,
-					Y: projectile.position.Y,
-					W: projectile.width,
-					H: projectile.height,
-				}
-				if sdl.HasRectIntersectionFloat(enemyRect, projectileRect) {
-					enemy.currentHealth -= projectile.damage
-					s.projectilesPlayer.Remove(e)
-					s.sounds["hit"].Play()
-					break
-				}
-			}
+			ai.direction.X = -1
 		}
-
-		e = next
-	}
-}
-
-func (s *sceneMain) updateEnemyProjectiles(deltaTime float32) {
-	// 子弹超出屏幕外边界的距离
-	margin := float32(32.0)
-
-	for e := s.projectilesEnemy.Front(); e != nil; {
-		next := e.Next()
-
-		projectile := e.Value.(*projectileEnemy)
-		projectile.position.X += projectile.speed * projectile.direction.X * deltaTime
-		projectile.position.Y += projectile.speed * projectile.direction.Y * deltaTime
-		if projectile.position.Y > float32(GetInstance().windowHeight)+margin ||
-			projectile.position.Y < -margin ||
-			projectile.position.X < -margin ||
-			projectile.position.X > float32(GetInstance().windowWidth)+margin {
-			s.projectilesEnemy.Remove(e)
-		} else {
-			projectileRect := sdl.FRect{
-				X: projectile.position.X,
-				Y: projectile.position.Y,
-				W: projectile.width,
-				H: projectile.height,
-			}
-			playerRect := sdl.FRect{
-				X: s.player.position.X,
-				Y: s.player.position.Y,
-				W: s.player.width,
-				H: s.player.height,
-			}
-			if sdl.HasRectIntersectionFloat(playerRect, projectileRect) && !s.isDead {
-				s.player.currentHealth -= projectile.damage
-				s.projectilesEnemy.Remove(e)
-				s.sounds["hit"].Play()
-				break
-			}
-		}
-
-		e = next
-	}
-}
-
-func (s *sceneMain) spawEnemy() {
-	dis := s.rand.Float32()
-	if dis > 1.0/60.0 {
-		return
 	}
-	enemy := s.enemyTemplate
-	enemy.position.X = s.rand.Float32() * (float32(GetInstance().windowWidth) - enemy.width)
-	enemy.position.Y = -enemy.height
-	s.enemies.PushBack(&enemy)
+	return e
 }
 
+// 移动敌人并射击，死亡的敌人由伤害系统移除
 func (s *sceneMain) updateEnemies(deltaTime float32) {
-	for e := s.enemies.Front(); e != nil; {
-		next := e.Next()
-
-		enemy := e.Value.(*enemy)
-		enemy.position.Y += enemy.speed * deltaTime
-
-		if enemy.position.Y > float32(GetInstance().windowHeight) {
-			s.enemies.Remove(e)
-		} else {
-			currentTime := sdl.GetTicks()
-			if enemy.currentHealth <= 0 {
-				s.enemyExplode(enemy)
-				s.enemies.Remove(e)
-				e = next
-				continue
-			}
-			if currentTime-enemy.lastShootTime > enemy.coolDown && !s.isDead {
-				s.shootEnemy(enemy)
-				enemy.lastShootTime = currentTime
-			}
+	w := s.world
+	currentTime := s.enemyTime()
+	for e := range w.query(componentTransform | componentEnemyAI) {
+		t := w.transforms.at(e)
+		ai := w.enemyAIs.at(e)
+		s.moveEnemy(t, ai, deltaTime)
+		if s.isDead || w.healths.at(e).current <= 0 {
+			continue
+		}
+		muzzle := t.center()
+		s.updateEmitter(&ai.emitter, muzzle, currentTime)
+		if currentTime-ai.lastShootTime > ai.coolDown && !ai.emitter.busy() {
+			s.emit(&ai.emitter, muzzle, currentTime)
+			ai.lastShootTime = currentTime
 		}
-
-		e = next
 	}
 }
 
-func (s *sceneMain) enemyExplode(enemy *enemy) {
-	currentTime := sdl.GetTicks()
-	explosion := s.explosionTemplate
-	explosion.position.X = enemy.position.X + enemy.width/2 - explosion.width/2
-	explosion.position.Y = enemy.position.Y + enemy.height/2 - explosion.height/2
-	explosion.startTime = currentTime
-	s.explosions.PushBack(&explosion)
-	s.sounds["enemy_explode"].Play()
-	if s.rand.Float32() < 0.5 {
-		s.dropItem(enemy)
-	}
-	s.score += 10
+// 敌人被击毁，爆炸、计分并按概率掉落物品
+func (s *sceneMain) enemyExplode(e entity) {
+	center := s.world.transforms.at(e).center()
+	s.addExplosion(center)
+	s.playSound("enemy_explode")
+	if s.rand.Float32() < s.dropChance {
+		s.dropItem(center)
+	}
+	s.score += s.world.enemyAIs.at(e).kind.score
 }
 
-func (s *sceneMain) shootEnemy(enemy *enemy) {
-	projectile := s.projectileEnemyTemplate
-	projectile.position.X = enemy.position.X + enemy.width/2 - projectile.width/2
-	projectile.position.Y = enemy.position.Y
-	projectile.direction = s.getDirection(enemy)
-	s.projectilesEnemy.PushBack(&projectile)
-	s.sounds["enemy_shoot"].Play()
+// 在center产生一个爆炸
+func (s *sceneMain) addExplosion(center sdl.FPoint) {
+	p := &s.explosionPrefab
+	position := sdl.FPoint{X: center.X - p.width/2, Y: center.Y - p.height/2}
+	e := s.spawn(p, position)
+	currentTime := s.currentTime()
+	s.world.sprites.at(e).startTime = currentTime
+	// 播放完后移除
+	duration := math.Ceil(float64(p.sprite.frames) * 1000 / float64(p.sprite.fps))
+	s.world.lifetimes.at(e).endTime = currentTime + uint64(duration)
 }
 
-func (s *sceneMain) getDirection(enemy *enemy) sdl.FPoint {
-	x := (s.player.position.X + s.player.width/2) - (enemy.position.X + enemy.width/2)
-	y := (s.player.position.Y + s.player.height/2) - (enemy.position.Y + enemy.height/2)
+// 从敌人中心朝玩家中心的方向
+func (s *sceneMain) getDirection(t *transform) sdl.FPoint {
+	x := (s.player.position.X + s.player.width/2) - (t.position.X + t.width/2)
+	y := (s.player.position.Y + s.player.height/2) - (t.position.Y + t.height/2)
 	length := math.Sqrt(float64(x*x + y*y))
 	x /= float32(length)
 	y /= float32(length)
 	return sdl.FPoint{X: x, Y: y}
 }
 
-func (s *sceneMain) renderEnemies() {
-	for e := s.enemies.Front(); e != nil; e = e.Next() {
-		enemy := e.Value.(*enemy)
-		ds := sdl.FRect{X: enemy.position.X, Y: enemy.position.Y, W: enemy.width, H: enemy.height}
-		sdl.RenderTexture(GetInstance().sdlRenderer, enemy.texture, nil, &ds)
-	}
+// 玩家中心
+func (s *sceneMain) playerCenter() sdl.FPoint {
+	return sdl.FPoint{X: s.player.position.X + s.player.width/2, Y: s.player.position.Y + s.player.height/2}
+}
+
+// 碰撞盒是否击中玩家判定点
+func (s *sceneMain) hitPlayerCore(h hitbox, position sdl.FPoint) bool {
+	return s.player.core.overlaps(s.player.position, h, position)
 }
 
 func (s *sceneMain) updatePlayer(float32) {
@@ -511,175 +738,102 @@ func (s *sceneMain) updatePlayer(float32) {
 
 	if s.player.currentHealth <= 0 {
 		s.isDead = true
-		currentTime := sdl.GetTicks()
-		explosion := s.explosionTemplate
-		explosion.position.X = s.player.position.X + s.player.width/2 - explosion.width/2
-		explosion.position.Y = s.player.position.Y + s.player.height/2 - explosion.height/2
-		explosion.startTime = currentTime
-		s.explosions.PushBack(&explosion)
-		s.sounds["player_explode"].Play()
+		s.deathTick = s.tick
+		s.addExplosion(s.playerCenter())
+		s.playSound("player_explode")
 		GetInstance().finalScore = s.score
+		GetInstance().finalSeed = s.seed
+		GetInstance().finalCleared = false
+		s.saveReplay()
 		return
 	}
-	for e := s.enemies.Front(); e != nil; e = e.Next() {
-		enemy := e.Value.(*enemy)
-		enemyRect := sdl.FRect{
-			X: enemy.position.X,
-			Y: enemy.position.Y,
-			W: enemy.width,
-			H: enemy.height,
-		}
-		playerRect := sdl.FRect{
-			X: s.player.position.X,
-			Y: s.player.position.Y,
-			W: s.player.width,
-			H: s.player.height,
-		}
-		if sdl.HasRectIntersectionFloat(playerRect, enemyRect) {
-			s.player.currentHealth -= 1
-			enemy.currentHealth = 0
-		}
-	}
 }
 
-func (s *sceneMain) updateExplosions(float32) {
-	currentTime := sdl.GetTicks()
-	for e := s.explosions.Front(); e != nil; {
-		next := e.Next()
-
-		explosion := e.Value.(*explosion)
-		explosion.currentFrame = float32(currentTime-explosion.startTime) / 1000.0 * float32(explosion.fps)
-		fmt.Printf("currentFrame: %f, totalFrame: %f\n", explosion.currentFrame, explosion.totalFrame)
-		if explosion.currentFrame >= explosion.totalFrame {
-			s.explosions.Remove(e)
-		}
-
-		e = next
-	}
-}
-
-func (s *sceneMain) renderExplosions() {
-	for e := s.explosions.Front(); e != nil; e = e.Next() {
-		explosion := e.Value.(*explosion)
-
-		frameIndex := int32(explosion.currentFrame)
-		if frameIndex >= int32(explosion.totalFrame) {
-			frameIndex = int32(explosion.totalFrame) - 1
-		}
-		if frameIndex < 0 {
-			frameIndex = 0
-		}
-
-		sc := sdl.FRect{
-			X: float32(frameIndex) * explosion.width,
-			Y: 0.0,
-			W: explosion.width,
-			H: explosion.height,
-		}
-		ds := sdl.FRect{
-			X: explosion.position.X,
-			Y: explosion.position.Y,
-			W: explosion.width,
-			H: explosion.height,
-		}
-		// fmt.Printf("sc: %f, %f, %f, %f\n", sc.X, sc.Y, sc.W, sc.H)
-		sdl.RenderTexture(GetInstance().sdlRenderer, explosion.texture, &sc, &ds)
-	}
-}
-
-func (s *sceneMain) dropItem(Enemy *enemy) {
-	item := s.itemLifeTemplate
-	item.position.X = Enemy.position.X + Enemy.width/2 - item.width/2
-	item.position.Y = Enemy.position.Y + Enemy.height/2 - item.height/2
+// 在position掉落一个随机物品，朝随机方向移动
+func (s *sceneMain) dropItem(position sdl.FPoint) {
+	w := s.world
+	kind := &s.itemKinds[pickWeighted(s.rand, s.itemWeights)]
+	position.X -= kind.prefab.width / 2
+	position.Y -= kind.prefab.height / 2
+	e := s.spawn(&kind.prefab, position)
 	angle := s.rand.Float64() * 2 * math.Pi
-	item.direction.X = float32(math.Cos(angle))
-	item.direction.Y = float32(math.Sin(angle))
-	s.items.PushBack(&item)
-}
-
-func (s *sceneMain) renderItems() {
-	for e := s.items.Front(); e != nil; e = e.Next() {
-		item := e.Value.(*item)
-		itemRect := sdl.FRect{
-			X: item.position.X,
-			Y: item.position.Y,
-			W: item.width,
-			H: item.height,
-		}
-		sdl.RenderTexture(GetInstance().sdlRenderer, item.texture, nil, &itemRect)
-	}
+	direction := sdl.FPoint{X: float32(math.Cos(angle)), Y: float32(math.Sin(angle))}
+	*w.velocities.add(w, e) = velocity{direction: direction, speed: kind.prefab.speed}
+	*w.pickups.add(w, e) = kind.pickup
 }
 
-func (s *sceneMain) updateItems(deltaTime float32) {
-	for e := s.items.Front(); e != nil; {
-		next := e.Next()
-
-		item := e.Value.(*item)
-		item.position.X += item.direction.X * item.speed * deltaTime
-		item.position.Y += item.direction.Y * item.speed * deltaTime
-
-		// 处理屏幕边缘反弹
-		if item.position.X < 0 && item.bounceCount > 0 {
-			item.direction.X = -item.direction.X
+// 物品碰到屏幕边缘时反弹，弹跳次数用完后离开屏幕
+func (s *sceneMain) updateItems() {
+	w := s.world
+	width := s.fieldWidth
+	height := s.fieldHeight
+	for e := range w.query(componentTransform | componentVelocity | componentPickup) {
+		t := w.transforms.at(e)
+		v := w.velocities.at(e)
+		item := w.pickups.at(e)
+		if t.position.X < 0 && item.bounceCount > 0 {
+			v.direction.X = -v.direction.X
 			item.bounceCount--
 		}
-		if item.position.X+item.width > float32(GetInstance().windowWidth) && item.bounceCount > 0 {
-			item.direction.X = -item.direction.X
+		if t.position.X+t.width > width && item.bounceCount > 0 {
+			v.direction.X = -v.direction.X
 			item.bounceCount--
 		}
-		if item.position.Y < 0 && item.bounceCount > 0 {
-			item.direction.Y = -item.direction.Y
+		if t.position.Y < 0 && item.bounceCount > 0 {
+			v.direction.Y = -v.direction.Y
 			item.bounceCount--
 		}
-		if item.position.Y+item.height > float32(GetInstance().windowHeight) && item.bounceCount > 0 {
-			item.direction.Y = -item.direction.Y
+		if t.position.Y+t.height > height && item.bounceCount > 0 {
+			v.direction.Y = -v.direction.Y
 			item.bounceCount--
 		}
-		// 如果超出屏幕范围则删除
-		if item.position.X+item.width < 0 ||
-			item.position.X > float32(GetInstance().windowWidth) ||
-			item.position.Y+item.height < 0 ||
-			item.position.Y > float32(GetInstance().windowHeight) {
-			s.items.Remove(e)
-		} else {
-			itemRect := sdl.FRect{
-				X: item.position.X,
-				Y: item.position.Y,
-				W: item.width,
-				H: item.height,
-			}
-			playerRect := sdl.FRect{
-				X: s.player.position.X,
-				Y: s.player.position.Y,
-				W: s.player.width,
-				H: s.player.height,
-			}
-			if sdl.HasRectIntersectionFloat(itemRect, playerRect) && !s.isDead {
-				s.playerGetItem(item)
-				s.items.Remove(e)
-			}
-		}
-
-		e = next
 	}
 }
 
-func (s *sceneMain) playerGetItem(item *item) {
+func (s *sceneMain) playerGetItem(item *pickup) {
 	s.score += 5
-	if item.itemType == itemTypeLife {
+	currentTime := s.currentTime()
+	switch item.itemType {
+	case itemTypeLife:
 		s.player.currentHealth += 1
 		if s.player.currentHealth > s.player.maxHealth {
 			s.player.currentHealth = s.player.maxHealth
 		}
-	}
-	s.sounds["get_item"].Play()
+	case itemTypeShield:
+		s.player.shield.start(currentTime, item.duration, item.warn)
+	case itemTypeTime:
+		s.timeSlow.start(currentTime, item.duration, item.warn)
+		s.timeScale = item.timeScale
+	case itemTypeWeapon:
+		s.pickWeapon(item.weapon)
+	case itemTypeBomb:
+		s.addBomb()
+	}
+	s.playSound("get_item")
 }
 
 func (s *sceneMain) changeSceneDelayed(deltaTime float32, delay float32) {
 	s.timerEnd += deltaTime
 	if s.timerEnd > delay {
-		GetInstance().changeScene(&sceneEnd{})
+		// 回放结束后回到标题场景，不进入排行榜
+		if s.replay != nil {
+			GetInstance().replaceScene(&sceneTitle{}, fadeTransition)
+			return
+		}
+		GetInstance().replaceScene(&sceneEnd{}, crossfadeTransition)
+	}
+}
+
+// 保存本局的回放文件
+func (s *sceneMain) saveReplay() {
+	if s.recorder == nil {
+		return
+	}
+	s.recorder.score = s.score
+	if err := s.recorder.save(newReplayPath()); err != nil {
+		fmt.Println(err)
 	}
+	s.recorder = nil
 }
 
 func (s *sceneMain) renderUI() {
@@ -709,6 +863,12 @@ func (s *sceneMain) renderUI() {
 		}
 		sdl.RenderTexture(GetInstance().sdlRenderer, s.uiHealth, nil, &rect)
 	}
+	// 渲染炸弹
+	s.renderBombs()
+	// 渲染武器
+	s.renderWeapon()
+	// 渲染Boss血条
+	s.renderBossHealth()
 	// 渲染得分
 	text := "SCORE:" + strconv.Itoa(int(s.score))
 	color := sdl.Color{R: 255, G: 255, B: 255, A: 255}
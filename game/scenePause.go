@@ -0,0 +1,241 @@
+package game
+
+import (
+	"github.com/SunshineZzzz/purego-sdl3/sdl"
+)
+
+// 暂停菜单页面
+type pausePage int32
+
+const (
+	// 主菜单
+	pausePageMain pausePage = iota
+	// 确认返回标题
+	pausePageConfirm
+	// 设置
+	pausePageSettings
+	// 按键设置
+	pausePageBindings
+)
+
+// 暂停主菜单选项
+const (
+	pauseMenuResume = iota
+	pauseMenuRestart
+	pauseMenuSettings
+	pauseMenuQuit
+)
+
+// 确认菜单选项
+const (
+	confirmMenuCancel = iota
+	confirmMenuOK
+)
+
+// 设置菜单选项
+const (
+	settingsMenuFullscreen = iota
+	settingsMenuBindings
+	settingsMenuBack
+)
+
+// 按键设置中可以修改的动作和显示的名字
+var rebindableActions = []struct {
+	action action
+	name   string
+}{
+	{actionMoveUp, "上移"},
+	{actionMoveDown, "下移"},
+	{actionMoveLeft, "左移"},
+	{actionMoveRight, "右移"},
+	{actionFire, "开火"},
+	{actionBomb, "炸弹"},
+	{actionFocus, "低速"},
+	{actionConfirm, "确认"},
+	{actionPause, "暂停"},
+}
+
+// 暂停场景，覆盖在sceneMain上面，sceneMain继续渲染但不更新
+type scenePause struct {
+	// 被暂停的场景
+	main *sceneMain
+	// 当前页面
+	page pausePage
+	// 当前页面的菜单
+	menu menu
+	// 等待新按键的动作在rebindableActions中的下标，-1表示不在等待
+	waiting int
+}
+
+var _ iscene = (*scenePause)(nil)
+
+// 暂停游戏，停止背景音乐并压入暂停场景
+func (s *sceneMain) pause() {
+	if s.bgm != nil {
+		s.bgm.Pause()
+	}
+	// 暂停期间停在当前逻辑帧，不做渲染插值
+	s.player.lastPosition = s.player.position
+	if s.boss != nil {
+		s.boss.lastPosition = s.boss.position
+	}
+	for e := range s.world.query(componentTransform) {
+		t := s.world.transforms.at(e)
+		t.lastPosition = t.position
+	}
+	GetInstance().pushScene(&scenePause{main: s})
+}
+
+func (s *scenePause) init() error {
+	s.waiting = -1
+	s.openPage(pausePageMain)
+	return nil
+}
+
+func (s *scenePause) update(float32) {
+}
+
+func (s *scenePause) clean() {
+}
+
+// 继续游戏，弹出暂停场景
+func (s *scenePause) resume() {
+	if s.main.bgm != nil {
+		s.main.bgm.Resume()
+	}
+	GetInstance().popScene()
+}
+
+// 切换页面
+func (s *scenePause) openPage(page pausePage) {
+	s.page = page
+	s.menu = menu{}
+	s.refreshMenu()
+}
+
+// 重新生成当前页面的选项文字，保留选中的选项
+func (s *scenePause) refreshMenu() {
+	switch s.page {
+	case pausePageMain:
+		s.menu.items = []string{"继续游戏", "重新开始", "设置", "返回标题"}
+	case pausePageConfirm:
+		s.menu.items = []string{"取消", "确定"}
+	case pausePageSettings:
+		fullscreen := "全屏：关"
+		if GetInstance().isFullscreen {
+			fullscreen = "全屏：开"
+		}
+		s.menu.items = []string{fullscreen, "按键设置", "返回"}
+	case pausePageBindings:
+		bindings := GetInstance().bindings
+		items := make([]string, 0, len(rebindableActions)+1)
+		for i, bound := range rebindableActions {
+			key := bindings.keyName(bound.action)
+			if i == s.waiting {
+				key = "..."
+			}
+			items = append(items, bound.name+"："+key)
+		}
+		s.menu.items = append(items, "返回")
+	}
+}
+
+// 处理暂停菜单事件，暂停键返回上一级
+func (s *scenePause) handleEvent(event sdl.Event) {
+	bindings := GetInstance().bindings
+
+	// 等待新按键时，下一个按下的键盘按键绑定到动作
+	if s.waiting >= 0 {
+		if event.Type() == sdl.EventKeyDown && !event.Key().Repeat {
+			GetInstance().rebind(rebindableActions[s.waiting].action, []sdl.Scancode{event.Key().Scancode})
+			s.waiting = -1
+			s.refreshMenu()
+		}
+		return
+	}
+
+	if bindings.isPressed(event, actionPause) {
+		switch s.page {
+		case pausePageMain:
+			s.resume()
+		case pausePageBindings:
+			s.openPage(pausePageSettings)
+		default:
+			s.openPage(pausePageMain)
+		}
+		return
+	}
+	if !s.menu.handleEvent(event) {
+		return
+	}
+
+	switch s.page {
+	case pausePageMain:
+		switch s.menu.selected {
+		case pauseMenuResume:
+			s.resume()
+		case pauseMenuRestart:
+			GetInstance().changeScene(&sceneMain{replay: s.main.replay}, wipeTransition)
+		case pauseMenuSettings:
+			s.openPage(pausePageSettings)
+		case pauseMenuQuit:
+			s.openPage(pausePageConfirm)
+		}
+	case pausePageConfirm:
+		switch s.menu.selected {
+		case confirmMenuCancel:
+			s.openPage(pausePageMain)
+			s.menu.selected = pauseMenuQuit
+		case confirmMenuOK:
+			GetInstance().changeScene(&sceneTitle{}, fadeTransition)
+		}
+	case pausePageSettings:
+		switch s.menu.selected {
+		case settingsMenuFullscreen:
+			GetInstance().toggleFullscreen()
+			s.refreshMenu()
+		case settingsMenuBindings:
+			s.openPage(pausePageBindings)
+		case settingsMenuBack:
+			s.openPage(pausePageMain)
+			s.menu.selected = pauseMenuSettings
+		}
+	case pausePageBindings:
+		if s.menu.selected == len(rebindableActions) {
+			s.openPage(pausePageSettings)
+			s.menu.selected = settingsMenuBindings
+			return
+		}
+		s.waiting = s.menu.selected
+		s.refreshMenu()
+	}
+}
+
+// 渲染暂停菜单，画面变暗后显示当前页面
+func (s *scenePause) render() {
+	rect := sdl.FRect{
+		X: 0,
+		Y: 0,
+		W: float32(GetInstance().windowWidth),
+		H: float32(GetInstance().windowHeight),
+	}
+	GetInstance().renderFillRect(rect, sdl.Color{R: 0, G: 0, B: 0, A: 160})
+
+	switch s.page {
+	case pausePageMain:
+		GetInstance().renderTextCentered("暂停", 0.3, true)
+		s.menu.render(0.5, 0.08)
+	case pausePageConfirm:
+		GetInstance().renderTextCentered("返回标题？本局进度将丢失", 0.35, false)
+		s.menu.render(0.5, 0.08)
+	case pausePageSettings:
+		GetInstance().renderTextCentered("设置", 0.3, true)
+		s.menu.render(0.5, 0.08)
+	case pausePageBindings:
+		GetInstance().renderTextCentered("按键设置", 0.15, true)
+		s.menu.render(0.3, 0.055)
+		if s.waiting >= 0 {
+			GetInstance().renderTextCentered("按下新的按键", 0.92, false)
+		}
+	}
+}
@@ -10,18 +10,44 @@ type sceneTitle struct {
 	bgm *oggPlayer
 	// 定时器
 	timer float32
+	// 菜单
+	menu menu
 }
 
-var _ iscene = (*sceneTitle)(nil)
+// 标题和结束场景的背景音乐
+const introMusicPath = "assets/music/06_Battle_in_Space_Intro.ogg"
 
-func (s *sceneTitle) init() {
-	bgm, err := newOggPlayer("assets/music/06_Battle_in_Space_Intro.ogg")
+// 标题场景使用的资源
+var sceneTitleAssets = assetManifest{music: []string{introMusicPath}}
+
+// 标题菜单选项
+const (
+	titleMenuStart = iota
+	titleMenuQuit
+)
+
+var _ loadableScene = (*sceneTitle)(nil)
+
+func (s *sceneTitle) manifest() (assetManifest, error) {
+	return sceneTitleAssets, nil
+}
+
+func (s *sceneTitle) init() error {
+	assets := GetInstance().assets
+	if err := assets.preload(&sceneTitleAssets); err != nil {
+		return err
+	}
+	bgm, err := assets.newMusicPlayer(introMusicPath)
 	if err != nil {
-		panic(err)
+		assets.release(&sceneTitleAssets)
+		return err
 	}
 	s.bgm = bgm
 	s.bgm.SetLoop(true)
 	s.bgm.Play()
+
+	s.menu = menu{items: []string{"开始游戏", "退出游戏"}}
+	return nil
 }
 
 func (s *sceneTitle) update(deltaTime float32) {
@@ -36,10 +62,12 @@ func (s *sceneTitle) render() {
 	titleText := "SDL太空战机"
 	GetInstance().renderTextCentered(titleText, 0.4, true)
 
+	// 渲染菜单
+	s.menu.render(0.7, 0.08)
 	// 渲染普通文字
 	if s.timer < 0.5 {
-		instructions := "按 J 键开始游戏"
-		GetInstance().renderTextCentered(instructions, 0.8, false)
+		instructions := "按 " + GetInstance().bindings.keyName(actionConfirm) + " 键确认"
+		GetInstance().renderTextCentered(instructions, 0.9, false)
 	}
 }
 
@@ -48,12 +76,17 @@ func (s *sceneTitle) clean() {
 		s.bgm.Close()
 		s.bgm = nil
 	}
+	GetInstance().assets.release(&sceneTitleAssets)
 }
 
 func (s *sceneTitle) handleEvent(event sdl.Event) {
-	if event.Type() == sdl.EventKeyDown {
-		if event.Key().Scancode == sdl.ScancodeJ {
-			GetInstance().changeScene(&sceneMain{})
-		}
+	if !s.menu.handleEvent(event) {
+		return
+	}
+	switch s.menu.selected {
+	case titleMenuStart:
+		GetInstance().replaceScene(&sceneMain{}, fadeTransition)
+	case titleMenuQuit:
+		GetInstance().isRunning = false
 	}
 }
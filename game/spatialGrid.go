@@ -0,0 +1,105 @@
+package game
+
+import (
+	"math"
+	"slices"
+
+	"github.com/SunshineZzzz/purego-sdl3/sdl"
+)
+
+// 碰撞网格的单元格大小，像素
+const gridCellSize = 64
+
+// 均匀网格，碰撞检测的粗略阶段，每个逻辑帧重建
+// 只有所在单元格有交集的实体才需要做精确的碰撞检测
+type spatialGrid[T any] struct {
+	// 单元格大小
+	cellSize float32
+	// 列数
+	cols int32
+	// 行数
+	rows int32
+	// 每个单元格中的值的下标
+	cells [][]int32
+	// 插入的值，按插入顺序
+	values []T
+	// 每个值最后一次被查询到的查询序号，用于去重
+	marks []uint32
+	// 当前查询序号
+	queryID uint32
+	// 查询结果的下标，重复使用避免分配
+	found []int32
+}
+
+// 创建网格
+func newSpatialGrid[T any](cellSize float32) *spatialGrid[T] {
+	return &spatialGrid[T]{cellSize: cellSize}
+}
+
+// 清空网格，覆盖范围为[0,width]x[0,height]，范围外的实体归入边缘的单元格
+func (g *spatialGrid[T]) reset(width float32, height float32) {
+	cols := max(1, int32(math.Ceil(float64(width/g.cellSize))))
+	rows := max(1, int32(math.Ceil(float64(height/g.cellSize))))
+	if cols != g.cols || rows != g.rows {
+		g.cols = cols
+		g.rows = rows
+		g.cells = make([][]int32, cols*rows)
+	} else {
+		for i := range g.cells {
+			g.cells[i] = g.cells[i][:0]
+		}
+	}
+	g.values = g.values[:0]
+	g.marks = g.marks[:0]
+}
+
+// 矩形覆盖的单元格范围，包含两端
+func (g *spatialGrid[T]) cellRange(bounds sdl.FRect) (int32, int32, int32, int32) {
+	clamp := func(value float32, count int32) int32 {
+		return max(0, min(count-1, int32(math.Floor(float64(value/g.cellSize)))))
+	}
+	return clamp(bounds.X, g.cols), clamp(bounds.Y, g.rows),
+		clamp(bounds.X+bounds.W, g.cols), clamp(bounds.Y+bounds.H, g.rows)
+}
+
+// 插入一个值，bounds为它的包围盒
+func (g *spatialGrid[T]) insert(value T, bounds sdl.FRect) {
+	index := int32(len(g.values))
+	g.values = append(g.values, value)
+	g.marks = append(g.marks, 0)
+	x0, y0, x1, y1 := g.cellRange(bounds)
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			cell := y*g.cols + x
+			g.cells[cell] = append(g.cells[cell], index)
+		}
+	}
+}
+
+// 查询和矩形所在单元格有交集的值，按插入顺序追加到out，同一个值只出现一次
+func (g *spatialGrid[T]) query(bounds sdl.FRect, out []T) []T {
+	g.queryID++
+	if g.queryID == 0 {
+		// 序号回绕，清除旧的标记
+		clear(g.marks)
+		g.queryID = 1
+	}
+	g.found = g.found[:0]
+	x0, y0, x1, y1 := g.cellRange(bounds)
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			for _, index := range g.cells[y*g.cols+x] {
+				if g.marks[index] != g.queryID {
+					g.marks[index] = g.queryID
+					g.found = append(g.found, index)
+				}
+			}
+		}
+	}
+	// 保持插入顺序，碰撞结果和逐个遍历时一致
+	slices.Sort(g.found)
+	for _, index := range g.found {
+		out = append(out, g.values[index])
+	}
+	return out
+}
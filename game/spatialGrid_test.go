@@ -0,0 +1,117 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/SunshineZzzz/purego-sdl3/sdl"
+)
+
+// 测试用的碰撞区域，和默认窗口一样大
+const (
+	testGridWidth  = 600
+	testGridHeight = 800
+)
+
+// 测试用的物体，位置和碰撞盒
+type testCollider struct {
+	position sdl.FPoint
+	hitbox   hitbox
+}
+
+// 生成count个随机物体，大小和子弹、敌人相近，有一部分在屏幕外
+func randomColliders(count int) []testCollider {
+	r := rand.New(rand.NewSource(int64(count)))
+	colliders := make([]testCollider, count)
+	for i := range colliders {
+		size := 8 + r.Float32()*40
+		var defs []hitboxDef
+		if r.Intn(2) == 0 {
+			defs = []hitboxDef{{Type: "circle", X: 0.5, Y: 0.5, R: 0.4}}
+		}
+		colliders[i] = testCollider{
+			position: sdl.FPoint{
+				X: r.Float32()*(testGridWidth+100) - 50,
+				Y: r.Float32()*(testGridHeight+100) - 50,
+			},
+			hitbox: newHitbox(defs, size, size),
+		}
+	}
+	return colliders
+}
+
+// 逐对检查，对每一对相交的物体调用visit
+func bruteForcePairs(colliders []testCollider, visit func(i int32, j int32)) {
+	for i := range colliders {
+		a := &colliders[i]
+		for j := i + 1; j < len(colliders); j++ {
+			b := &colliders[j]
+			if a.hitbox.overlaps(a.position, b.hitbox, b.position) {
+				visit(int32(i), int32(j))
+			}
+		}
+	}
+}
+
+// 用网格查找候选对，对每一对候选调用candidate，返回重复使用的查询结果
+func gridPairs(grid *spatialGrid[int32], colliders []testCollider, found []int32, candidate func(i int32, j int32)) []int32 {
+	grid.reset(testGridWidth, testGridHeight)
+	for i := range colliders {
+		c := &colliders[i]
+		grid.insert(int32(i), c.hitbox.bounds(c.position))
+	}
+	for i := range colliders {
+		c := &colliders[i]
+		found = grid.query(c.hitbox.bounds(c.position), found[:0])
+		for _, j := range found {
+			if j > int32(i) {
+				candidate(int32(i), j)
+			}
+		}
+	}
+	return found
+}
+
+// 网格给出的候选对必须包含所有实际相交的物体对
+func TestSpatialGridCandidatesCoverOverlaps(t *testing.T) {
+	for _, count := range []int{100, 1000, 5000} {
+		colliders := randomColliders(count)
+		candidates := make(map[[2]int32]bool)
+		gridPairs(newSpatialGrid[int32](gridCellSize), colliders, nil, func(i int32, j int32) {
+			candidates[[2]int32{i, j}] = true
+		})
+		overlaps := 0
+		bruteForcePairs(colliders, func(i int32, j int32) {
+			overlaps++
+			if !candidates[[2]int32{i, j}] {
+				t.Errorf("count %v: overlapping pair %v, %v missing from grid candidates", count, i, j)
+			}
+		})
+		if overlaps == 0 {
+			t.Errorf("count %v: no overlapping pairs, test data too sparse", count)
+		}
+	}
+}
+
+// 逐对检查和网格检查的性能对比
+func BenchmarkCollisionPairs(b *testing.B) {
+	for _, count := range []int{1000, 5000, 10000} {
+		colliders := randomColliders(count)
+		b.Run(fmt.Sprintf("brute/%d", count), func(b *testing.B) {
+			for b.Loop() {
+				bruteForcePairs(colliders, func(int32, int32) {})
+			}
+		})
+		b.Run(fmt.Sprintf("grid/%d", count), func(b *testing.B) {
+			grid := newSpatialGrid[int32](gridCellSize)
+			var found []int32
+			for b.Loop() {
+				found = gridPairs(grid, colliders, found, func(i int32, j int32) {
+					a, c := &colliders[i], &colliders[j]
+					a.hitbox.overlaps(a.position, c.hitbox, c.position)
+				})
+			}
+		})
+	}
+}
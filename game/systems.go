@@ -0,0 +1,215 @@
+package game
+
+import (
+	"iter"
+	"math"
+	"slices"
+
+	"github.com/SunshineZzzz/purego-sdl3/sdl"
+)
+
+// 伤害事件，由伤害系统统一结算
+type damageEvent struct {
+	// 受到伤害的实体
+	target entity
+	// 伤害
+	amount int32
+}
+
+// 实体使用的时间步长，敌方阵营受时间减速影响
+func (s *sceneMain) entityDeltaTime(e entity, deltaTime float32, enemyDeltaTime float32) float32 {
+	if t := s.world.teams.get(s.world, e); t != nil && *t == teamEnemy {
+		return enemyDeltaTime
+	}
+	return deltaTime
+}
+
+// 移动系统，保存所有实体上一逻辑帧的位置，再按速度移动
+func (s *sceneMain) movementSystem(deltaTime float32, enemyDeltaTime float32) {
+	w := s.world
+	for e := range w.query(componentTransform) {
+		t := w.transforms.at(e)
+		t.lastPosition = t.position
+	}
+	for e := range w.query(componentTransform | componentVelocity) {
+		t := w.transforms.at(e)
+		v := w.velocities.at(e)
+		dt := s.entityDeltaTime(e, deltaTime, enemyDeltaTime)
+		t.position.X += v.direction.X * v.speed * dt
+		t.position.Y += v.direction.Y * v.speed * dt
+	}
+}
+
+// 可以被玩家子弹击中的实体，即有生命值和碰撞体的敌方实体
+func (s *sceneMain) targets() iter.Seq[entity] {
+	w := s.world
+	return func(yield func(entity) bool) {
+		for e := range w.query(componentTransform | componentCollider | componentHealth | componentTeam) {
+			if *w.teams.at(e) == teamEnemy && !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+// 碰撞系统，检测不同阵营之间的碰撞
+// 玩家子弹击中敌人记为伤害事件，敌方实体撞上玩家判定点时伤害玩家，物品碰到玩家时被拾取
+func (s *sceneMain) collisionSystem() {
+	w := s.world
+	width := s.fieldWidth
+	height := s.fieldHeight
+
+	s.targetGrid.reset(width, height)
+	for e := range s.targets() {
+		s.targetGrid.insert(e, w.colliders.at(e).hitbox.bounds(w.transforms.at(e).position))
+	}
+	for e := range w.query(componentTransform | componentCollider | componentTeam) {
+		if *w.teams.at(e) != teamPlayer {
+			continue
+		}
+		if s.hitTargets(e) || s.hitBoss(e) {
+			w.destroy(e)
+		}
+	}
+	if s.isDead {
+		return
+	}
+
+	// 只检查玩家判定点附近的敌方实体
+	s.hostileGrid.reset(width, height)
+	for e := range w.query(componentTransform | componentCollider | componentTeam) {
+		if *w.teams.at(e) == teamEnemy {
+			s.hostileGrid.insert(e, w.colliders.at(e).hitbox.bounds(w.transforms.at(e).position))
+		}
+	}
+	s.nearby = s.hostileGrid.query(s.player.core.bounds(s.player.position), s.nearby[:0])
+	for _, e := range s.nearby {
+		t := w.transforms.at(e)
+		c := w.colliders.at(e)
+		if !s.hitPlayerCore(c.hitbox, t.position) {
+			continue
+		}
+		// 伤害生效时，有生命值的实体（敌人）撞上玩家后自毁，子弹击中玩家后消失
+		// 无敌时敌人和子弹都穿过玩家，不能靠无敌时间撞毁敌人得分
+		if !s.damagePlayer(c.damage, t.center()) {
+			continue
+		}
+		if h := w.healths.get(w, e); h != nil {
+			h.current = 0
+		} else {
+			w.destroy(e)
+		}
+	}
+
+	// 物品使用玩家的拾取碰撞盒，比判定点大
+	s.pickupGrid.reset(width, height)
+	for e := range w.query(componentTransform | componentCollider | componentPickup) {
+		s.pickupGrid.insert(e, w.colliders.at(e).hitbox.bounds(w.transforms.at(e).position))
+	}
+	s.nearby = s.pickupGrid.query(s.player.hitbox.bounds(s.player.position), s.nearby[:0])
+	for _, e := range s.nearby {
+		t := w.transforms.at(e)
+		if w.colliders.at(e).hitbox.overlaps(t.position, s.player.hitbox, s.player.position) {
+			s.playerGetItem(w.pickups.at(e))
+			w.destroy(e)
+		}
+	}
+}
+
+// 玩家子弹击中敌人，穿透子弹对同一个敌人只造成一次伤害，返回子弹是否消耗掉
+func (s *sceneMain) hitTargets(projectile entity) bool {
+	w := s.world
+	position := w.transforms.at(projectile).position
+	c := w.colliders.at(projectile)
+	s.nearby = s.targetGrid.query(c.hitbox.bounds(position), s.nearby[:0])
+	for _, e := range s.nearby {
+		if !c.hitbox.overlaps(position, w.colliders.at(e).hitbox, w.transforms.at(e).position) || slices.Contains(c.hits, e) {
+			continue
+		}
+		s.damages = append(s.damages, damageEvent{target: e, amount: c.damage})
+		s.playSound("hit")
+		if c.pierce == 0 {
+			return true
+		}
+		c.pierce--
+		c.hits = append(c.hits, e)
+	}
+	return false
+}
+
+// 伤害系统，结算本逻辑帧的伤害事件，生命值耗尽的实体死亡并移除
+func (s *sceneMain) damageSystem() {
+	w := s.world
+	for _, event := range s.damages {
+		if h := w.healths.get(w, event.target); h != nil {
+			h.current -= event.amount
+		}
+	}
+	s.damages = s.damages[:0]
+	for e := range w.query(componentHealth) {
+		if w.healths.at(e).current > 0 {
+			continue
+		}
+		if w.has(e, componentTransform|componentEnemyAI) {
+			s.enemyExplode(e)
+		}
+		w.destroy(e)
+	}
+}
+
+// 清理系统，移除超时或者离开屏幕的实体
+func (s *sceneMain) cleanupSystem() {
+	w := s.world
+	currentTime := s.currentTime()
+	width := s.fieldWidth
+	height := s.fieldHeight
+	for e := range w.query(componentTransform | componentLifetime) {
+		l := w.lifetimes.at(e)
+		if l.endTime != 0 && currentTime >= l.endTime {
+			w.destroy(e)
+			continue
+		}
+		if !l.offscreen {
+			continue
+		}
+		t := w.transforms.at(e)
+		if l.allowAbove && t.position.Y >= 0 {
+			// 完全进入屏幕后，从上方离开也要移除，例如向上俯冲的敌人
+			l.allowAbove = false
+		}
+		if t.position.X+t.width < -l.margin ||
+			t.position.X > width+l.margin ||
+			t.position.Y > height+l.margin ||
+			(!l.allowAbove && t.position.Y+t.height < -l.margin) {
+			w.destroy(e)
+		}
+	}
+}
+
+// 渲染系统，渲染一层的精灵，位置按插值计算
+func (s *sceneMain) renderSystem(layer renderLayer) {
+	w := s.world
+	renderer := GetInstance().sdlRenderer
+	for e := range w.query(componentTransform | componentSprite) {
+		sp := w.sprites.at(e)
+		if sp.layer != layer {
+			continue
+		}
+		t := w.transforms.at(e)
+		position := GetInstance().interpolate(t.lastPosition, t.position)
+		ds := sdl.FRect{X: position.X, Y: position.Y, W: t.width, H: t.height}
+		switch {
+		case sp.frames > 0:
+			frameIndex := int32(float32(s.currentTime()-sp.startTime) / 1000.0 * float32(sp.fps))
+			frameIndex = max(0, min(int32(sp.frames)-1, frameIndex))
+			sc := sdl.FRect{X: float32(frameIndex) * t.width, Y: 0, W: t.width, H: t.height}
+			sdl.RenderTexture(renderer, sp.texture, &sc, &ds)
+		case sp.rotate && w.has(e, componentVelocity):
+			direction := w.velocities.at(e).direction
+			angle := math.Atan2(float64(direction.Y), float64(direction.X))*180/math.Pi + sp.angleOffset
+			sdl.RenderTextureRotated(renderer, sp.texture, nil, &ds, angle, nil, sdl.FlipNone)
+		default:
+			sdl.RenderTexture(renderer, sp.texture, nil, &ds)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/SunshineZzzz/purego-sdl3/sdl"
+)
+
+// 场景切换效果
+type transitionKind int32
+
+const (
+	// 直接切换
+	transitionNone transitionKind = iota
+	// 旧画面淡出到黑色，新场景再从黑色淡入
+	transitionFade
+	// 旧画面逐渐透明，露出新场景
+	transitionCrossfade
+	// 新场景从左到右擦除旧画面
+	transitionWipe
+)
+
+// 场景切换
+type transition struct {
+	// 切换效果
+	kind transitionKind
+	// 持续时间，秒
+	duration float32
+}
+
+var (
+	// 淡出淡入
+	fadeTransition = transition{kind: transitionFade, duration: 0.8}
+	// 交叉淡化
+	crossfadeTransition = transition{kind: transitionCrossfade, duration: 0.6}
+	// 擦除
+	wipeTransition = transition{kind: transitionWipe, duration: 0.5}
+)
+
+// 正在进行的场景切换
+type transitionState struct {
+	transition
+	// 已经播放的时间，秒
+	elapsed float32
+	// 切换前的画面，为空时没有正在进行的切换
+	snapshot *sdl.Texture
+}
+
+// 开始场景切换，截取当前画面，切换期间新场景在旧画面下面渲染
+func (g *Game) beginTransition(t transition) {
+	g.endTransition()
+	if t.kind == transitionNone || t.duration <= 0 || g.headless || len(g.scenes) == 0 {
+		return
+	}
+	snapshot := sdl.CreateTexture(g.sdlRenderer, sdl.PixelFormatRGBA8888, sdl.TextureAccessTarget, g.windowWidth, g.windowHeight)
+	if snapshot == nil {
+		fmt.Printf("failed to create transition snapshot, %v\n", sdl.GetError())
+		return
+	}
+	sdl.SetTextureBlendMode(snapshot, sdl.BlendModeBlend)
+	sdl.SetRenderTarget(g.sdlRenderer, snapshot)
+	sdl.RenderClear(g.sdlRenderer)
+	g.renderBackground()
+	g.renderScenes()
+	sdl.SetRenderTarget(g.sdlRenderer, nil)
+	g.transition = transitionState{transition: t, snapshot: snapshot}
+}
+
+// 结束场景切换，释放截图
+func (g *Game) endTransition() {
+	if g.transition.snapshot != nil {
+		sdl.DestroyTexture(g.transition.snapshot)
+	}
+	g.transition = transitionState{}
+}
+
+// 是否正在切换场景，切换期间场景不更新也不接收事件
+func (g *Game) isTransitioning() bool {
+	return g.transition.snapshot != nil
+}
+
+// 推进场景切换，播放完后结束
+func (g *Game) updateTransition(deltaTime float32) {
+	if !g.isTransitioning() {
+		return
+	}
+	g.transition.elapsed += deltaTime
+	if g.transition.elapsed >= g.transition.duration {
+		g.endTransition()
+	}
+}
+
+// 在新场景上面渲染切换效果
+func (g *Game) renderTransition() {
+	if !g.isTransitioning() {
+		return
+	}
+	t := &g.transition
+	progress := min(1, t.elapsed/t.duration)
+	full := sdl.FRect{X: 0, Y: 0, W: float32(g.windowWidth), H: float32(g.windowHeight)}
+	switch t.kind {
+	case transitionFade:
+		// 前一半旧画面变黑，后一半新场景从黑色出现
+		if progress < 0.5 {
+			sdl.RenderTexture(g.sdlRenderer, t.snapshot, nil, &full)
+			g.renderFillRect(full, sdl.Color{R: 0, G: 0, B: 0, A: uint8(255 * progress * 2)})
+		} else {
+			g.renderFillRect(full, sdl.Color{R: 0, G: 0, B: 0, A: uint8(255 * (1 - progress) * 2)})
+		}
+	case transitionCrossfade:
+		sdl.SetTextureAlphaMod(t.snapshot, uint8(255*(1-progress)))
+		sdl.RenderTexture(g.sdlRenderer, t.snapshot, nil, &full)
+	case transitionWipe:
+		// 只渲染旧画面还没有被擦除的右侧部分
+		x := full.W * progress
+		rest := sdl.FRect{X: x, Y: 0, W: full.W - x, H: full.H}
+		sdl.RenderTexture(g.sdlRenderer, t.snapshot, &rest, &rest)
+	}
+}
@@ -2,7 +2,10 @@ package game
 
 import (
 	"encoding/binary"
+	"math"
 	"unsafe"
+
+	"github.com/SunshineZzzz/purego-sdl3/sdl"
 )
 
 // float32切片转换为字节切片
@@ -13,3 +16,40 @@ func float32ToBytes(data []float32) []byte {
 	}
 	return bytes
 }
+
+// 线性插值
+func lerp(a float32, b float32, t float32) float32 {
+	return a + (b-a)*t
+}
+
+// 点的线性插值
+func lerpPoint(a sdl.FPoint, b sdl.FPoint, t float32) sdl.FPoint {
+	return sdl.FPoint{X: lerp(a.X, b.X, t), Y: lerp(a.Y, b.Y, t)}
+}
+
+// 旋转方向向量，angle为弧度
+func rotatePoint(p sdl.FPoint, angle float32) sdl.FPoint {
+	sin, cos := math.Sincos(float64(angle))
+	return sdl.FPoint{
+		X: p.X*float32(cos) - p.Y*float32(sin),
+		Y: p.X*float32(sin) + p.Y*float32(cos),
+	}
+}
+
+// 方向from转向to需要的角度，弧度，范围[-π,π]
+func turnAngle(from sdl.FPoint, to sdl.FPoint) float32 {
+	cross := from.X*to.Y - from.Y*to.X
+	dot := from.X*to.X + from.Y*to.Y
+	return float32(math.Atan2(float64(cross), float64(dot)))
+}
+
+// 从from指向to的单位向量，两点重合时返回fallback
+func directionTo(from sdl.FPoint, to sdl.FPoint, fallback sdl.FPoint) sdl.FPoint {
+	x := to.X - from.X
+	y := to.Y - from.Y
+	length := float32(math.Sqrt(float64(x*x + y*y)))
+	if length == 0 {
+		return fallback
+	}
+	return sdl.FPoint{X: x / length, Y: y / length}
+}
@@ -33,7 +33,7 @@ func unregisterWavPlayer(id uint32) {
 type wavPlayer struct {
 	// SDL音频流
 	stream *sdl.AudioStream
-	// WAV音频数据
+	// WAV音频数据，和资源缓存共享，只读
 	audioBuf *uint8
 	// WAV音频数据长度
 	audioLen int
@@ -49,11 +49,22 @@ type wavPlayer struct {
 	id uint32
 }
 
-func newWavPlayer(soundFilePath string) (*wavPlayer, error) {
-	// 打开文件IO流
-	ioStream := sdl.IOFromFile(soundFilePath, "rb")
+// 解码后的WAV音效，多个播放器共享
+type wavData struct {
+	// 音频数据，由SDL分配
+	buf *uint8
+	// 音频数据长度
+	len int
+	// 音频规格
+	spec *sdl.AudioSpec
+}
+
+// 解码WAV文件数据，可以在后台协程调用
+func loadWav(soundFilePath string, data []byte) (*wavData, error) {
+	// 打开内存IO流
+	ioStream := sdl.IOFromConstMem(data)
 	if ioStream == nil {
-		return nil, fmt.Errorf("failed to open WAV file: %s", sdl.GetError())
+		return nil, fmt.Errorf("failed to open WAV file, %v, %v", soundFilePath, sdl.GetError())
 	}
 	// 自动释放了
 	// defer sdl.CloseIO(ioStream)
@@ -65,13 +76,26 @@ func newWavPlayer(soundFilePath string) (*wavPlayer, error) {
 	// 加载WAV数据
 	success := sdl.LoadWAVIO(ioStream, true, spec, &audioBuf, &audioLen)
 	if !success {
-		return nil, fmt.Errorf("failed to load WAV data: %s", sdl.GetError())
+		return nil, fmt.Errorf("failed to load WAV data, %v, %v", soundFilePath, sdl.GetError())
 	}
+	return &wavData{buf: audioBuf, len: int(audioLen), spec: spec}, nil
+}
 
+// 释放WAV音频数据
+func (d *wavData) free() {
+	if d.buf != nil {
+		sdl.Free(unsafe.Pointer(d.buf))
+		d.buf = nil
+		d.len = 0
+	}
+}
+
+// 由WAV音效创建播放器
+func newWavPlayer(data *wavData) (*wavPlayer, error) {
 	player := &wavPlayer{
-		audioBuf:  audioBuf,
-		audioLen:  int(audioLen),
-		spec:      spec,
+		audioBuf:  data.buf,
+		audioLen:  data.len,
+		spec:      data.spec,
 		dataPos:   0,
 		isPlaying: false,
 		loop:      false,
@@ -84,13 +108,13 @@ func newWavPlayer(soundFilePath string) (*wavPlayer, error) {
 	callback := sdl.NewAudioStreamCallback(wavAudioCallback)
 	player.stream = sdl.OpenAudioDeviceStream(
 		sdl.AudioDeviceDefaultPlayback,
-		spec,
+		data.spec,
 		callback,
 		unsafe.Pointer(uintptr(player.id)),
 	)
 
 	if player.stream == nil {
-		sdl.Free(unsafe.Pointer(audioBuf))
+		unregisterWavPlayer(player.id)
 		return nil, fmt.Errorf("failed to open audio stream: %s", sdl.GetError())
 	}
 
@@ -175,10 +199,8 @@ func (p *wavPlayer) Close() {
 		sdl.DestroyAudioStream(p.stream)
 		p.stream = nil
 	}
-	if p.audioLen > 0 {
-		sdl.Free(unsafe.Pointer(p.audioBuf))
-		p.audioBuf = nil
-		p.audioLen = 0
-	}
+	// 音频数据由资源缓存释放
+	p.audioBuf = nil
+	p.audioLen = 0
 	unregisterWavPlayer(p.id)
 }
@@ -0,0 +1,170 @@
+package game
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/SunshineZzzz/purego-sdl3/sdl"
+	"github.com/SunshineZzzz/purego-sdl3/ttf"
+)
+
+// 武器等级
+type weaponLevel struct {
+	// 子弹预制体
+	prefab prefab
+	// 追踪敌人的最大转向速度，弧度每秒，0表示不追踪
+	homingRate float32
+	// 可以穿透的敌人数量
+	pierce int32
+	// 射击冷却时间，毫秒
+	coolDown uint64
+	// 每次射击的子弹数量
+	count int32
+	// 扇形角度，弧度
+	spread float64
+	// 并排子弹的水平间距
+	spacing float32
+}
+
+// 武器
+type weapon struct {
+	// 名字
+	name string
+	// 等级列表，从低到高
+	levels []weaponLevel
+}
+
+// 由定义创建武器，使用预载的子弹纹理
+func (s *sceneMain) newWeapon(def *weaponDef) (*weapon, error) {
+	w := &weapon{name: def.Name, levels: make([]weaponLevel, len(def.Levels))}
+	for i := range def.Levels {
+		levelDef := &def.Levels[i]
+		level := &w.levels[i]
+		p := &level.prefab
+		var err error
+		p.sprite.texture, err = s.assetTexture(levelDef.Texture, &p.width, &p.height)
+		if err != nil {
+			return nil, err
+		}
+		p.sprite.layer = layerPlayerProjectile
+		// 纹理朝上
+		p.sprite.rotate = true
+		p.sprite.angleOffset = 90
+		p.width /= levelDef.ScaleDivisor
+		p.height /= levelDef.ScaleDivisor
+		p.team = teamPlayer
+		p.hitbox = newHitbox(levelDef.Hitbox, p.width, p.height)
+		p.damage = levelDef.Damage
+		p.speed = levelDef.Speed
+		p.lifetime = lifetime{offscreen: true, margin: projectileMargin}
+		level.homingRate = levelDef.HomingDegPerSec * math.Pi / 180
+		level.pierce = levelDef.Pierce
+		level.coolDown = levelDef.CoolDownMs
+		level.count = levelDef.Count
+		level.spread = float64(levelDef.SpreadDeg) * math.Pi / 180
+		level.spacing = levelDef.Spacing
+	}
+	return w, nil
+}
+
+// 切换武器和等级，等级超出范围时取最近的有效等级
+func (s *sceneMain) setWeapon(w *weapon, level int) {
+	level = max(0, min(len(w.levels)-1, level))
+	s.player.weapon = w
+	s.player.weaponLevel = level
+	s.player.coolDown = w.levels[level].coolDown
+}
+
+// 拾取武器，相同武器升级，不同武器切换并保留等级
+func (s *sceneMain) pickWeapon(w *weapon) {
+	if s.player.weapon == w {
+		s.setWeapon(w, s.player.weaponLevel+1)
+		return
+	}
+	s.setWeapon(w, s.player.weaponLevel)
+}
+
+func (s *sceneMain) shootPlayer() {
+	w := s.world
+	level := &s.player.weapon.levels[s.player.weaponLevel]
+	centerX := s.player.position.X + s.player.width/2
+	for i := int32(0); i < level.count; i++ {
+		// 相对中间子弹的序号
+		index := float32(i) - float32(level.count-1)/2
+		position := sdl.FPoint{X: centerX + index*level.spacing - level.prefab.width/2, Y: s.player.position.Y}
+		e := s.spawn(&level.prefab, position)
+		w.colliders.at(e).pierce = level.pierce
+		direction := sdl.FPoint{X: 0, Y: -1}
+		if level.count > 1 && level.spread > 0 {
+			angle := float32(level.spread) * index / float32(level.count-1)
+			direction = rotatePoint(direction, angle)
+		}
+		*w.velocities.add(w, e) = velocity{direction: direction, speed: level.prefab.speed}
+		if level.homingRate > 0 {
+			*w.homings.add(w, e) = homing{rate: level.homingRate}
+		}
+	}
+	s.playSound("player_shoot")
+}
+
+// 追踪子弹转向最近的敌人
+func (s *sceneMain) homingSystem(deltaTime float32) {
+	w := s.world
+	for e := range w.query(componentTransform | componentVelocity | componentHoming) {
+		center := w.transforms.at(e).center()
+		if target, ok := s.homingTarget(center); ok {
+			v := w.velocities.at(e)
+			diff := turnAngle(v.direction, directionTo(center, target, v.direction))
+			limit := w.homings.at(e).rate * deltaTime
+			v.direction = rotatePoint(v.direction, max(-limit, min(limit, diff)))
+		}
+	}
+}
+
+// 离from最近的目标中心，Boss以弱点为目标
+func (s *sceneMain) homingTarget(from sdl.FPoint) (sdl.FPoint, bool) {
+	var target sdl.FPoint
+	found := false
+	best := float32(math.MaxFloat32)
+	consider := func(point sdl.FPoint) {
+		x := point.X - from.X
+		y := point.Y - from.Y
+		if distance := x*x + y*y; distance < best {
+			best = distance
+			target = point
+			found = true
+		}
+	}
+	for e := range s.targets() {
+		consider(s.world.transforms.at(e).center())
+	}
+	if b := s.boss; b != nil && !b.dying {
+		for i := range b.kind.weakPoints {
+			rect := b.weakPointRect(&b.kind.weakPoints[i])
+			consider(sdl.FPoint{X: rect.X + rect.W/2, Y: rect.Y + rect.H/2})
+		}
+	}
+	return target, found
+}
+
+// 在左下角渲染当前武器和等级
+func (s *sceneMain) renderWeapon() {
+	w := s.player.weapon
+	text := strings.ToUpper(w.name) + " Lv." + strconv.Itoa(s.player.weaponLevel+1)
+	if s.player.weaponLevel == len(w.levels)-1 {
+		text += " MAX"
+	}
+	color := sdl.Color{R: 255, G: 255, B: 255, A: 255}
+	surface := ttf.RenderTextSolid(s.scoreFont, text, 0, color)
+	texture := sdl.CreateTextureFromSurface(GetInstance().sdlRenderer, surface)
+	rect := sdl.FRect{
+		X: 10,
+		Y: float32(GetInstance().windowHeight - 10 - surface.H),
+		W: float32(surface.W),
+		H: float32(surface.H),
+	}
+	sdl.RenderTexture(GetInstance().sdlRenderer, texture, nil, &rect)
+	sdl.DestroySurface(surface)
+	sdl.DestroyTexture(texture)
+}
@@ -1,12 +1,56 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"os"
 	"sdlshoot/game"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "pack" {
+		runPack(os.Args[2:])
+		return
+	}
+
+	headless := flag.Bool("headless", false, "无头模式运行，不创建窗口和音频设备")
+	script := flag.String("script", "", "无头模式下的输入脚本")
+	maxTicks := flag.Uint64("ticks", 120*60*10, "无头模式下最多运行的逻辑帧数")
+	seed := flag.Int64("seed", 0, "随机种子，不指定时每局随机生成")
+	replay := flag.String("replay", "", "播放回放文件")
+	verify := flag.String("verify", "", "无头模式重新模拟回放文件并校验得分")
+	fps := flag.Uint("fps", 240, "渲染帧率上限，0表示不限制，和逻辑更新频率无关")
+	flag.Parse()
+
 	game := game.GetInstance()
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			game.SetSeed(*seed)
+		}
+	})
+	if *verify != "" {
+		result, err := game.VerifyReplay(*verify)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Printf("replay ok, seed: %d, score: %d, ticks: %d\n", result.Seed, result.Score, result.Ticks)
+		return
+	}
+	if *headless {
+		result, err := game.RunHeadless(*script, *maxTicks)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Printf("seed: %d, score: %d, ticks: %d, dead: %v, death tick: %d, cleared: %v\n", result.Seed, result.Score, result.Ticks, result.IsDead, result.DeathTick, result.Cleared)
+		return
+	}
+
+	if *replay != "" {
+		game.SetReplay(*replay)
+	}
+	game.SetFrameRate(uint32(*fps))
 	if err := game.Init(); err != nil {
 		fmt.Println(err)
 		return
@@ -14,3 +58,35 @@ func main() {
 	game.Run()
 	game.Clean()
 }
+
+// pack子命令，把资源目录打包成一个资源包，或者校验已有的资源包
+func runPack(args []string) {
+	flags := flag.NewFlagSet("pack", flag.ExitOnError)
+	output := flags.String("o", "assets.pak", "输出的资源包文件")
+	check := flags.String("check", "", "校验资源包中所有文件的校验和")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "用法: sdlshoot pack [-o assets.pak] [资源目录，默认assets]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if *check != "" {
+		count, err := game.CheckPack(*check)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Printf("pack ok, %d files\n", count)
+		return
+	}
+	dir := "assets"
+	if flags.NArg() > 0 {
+		dir = flags.Arg(0)
+	}
+	count, err := game.BuildPack(dir, *output)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	fmt.Printf("packed %d files into %s\n", count, *output)
+}